@@ -0,0 +1,104 @@
+package apm
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// IntervalPathSeparator separates names of nested intervals in IntervalScope.Path.
+const IntervalPathSeparator = "/"
+
+type (
+	intervalScopeKey struct{}
+	roomIDKey        struct{}
+	serverIDKey      struct{}
+)
+
+// IntervalScope is interval started with context. Intervals started with its context are its children.
+type IntervalScope struct {
+	ID       uint64
+	ParentID uint64 // ParentID is 0 for root interval.
+	Name     string // Name is full name with prefix.
+	Path     string // Path is names from root interval to this one.
+	RoomID   uint64 // RoomID is 0 if unknown.
+	ServerID uint64 // ServerID is 0 if unknown.
+	Started  time.Time
+
+	parent   *IntervalScope
+	children int64 // children is total duration of finished children in nanoseconds, atomic.
+}
+
+// scopedInterval is implemented by intervals of this package, so MultiIntervals share one scope.
+type scopedInterval interface {
+	name(name string) string
+	// startScope returns func called on end with total duration and duration without children.
+	startScope(scope *IntervalScope) func(duration, self time.Duration)
+}
+
+// IntervalScopeFromContext returns interval started with ctx or nil.
+func IntervalScopeFromContext(ctx context.Context) *IntervalScope {
+	res, _ := ctx.Value(intervalScopeKey{}).(*IntervalScope)
+
+	return res
+}
+
+// ContextWithRoomID returns ctx, intervals started with it are marked with room id.
+func ContextWithRoomID(ctx context.Context, roomID uint64) context.Context {
+	return context.WithValue(ctx, roomIDKey{}, roomID)
+}
+
+// ContextWithServerID returns ctx, intervals started with it are marked with session server id.
+func ContextWithServerID(ctx context.Context, serverID uint64) context.Context {
+	return context.WithValue(ctx, serverIDKey{}, serverID)
+}
+
+func newIntervalScope(ctx context.Context, name string) *IntervalScope {
+	res := &IntervalScope{
+		ID:      atomic.AddUint64(&id, 1),
+		Name:    name,
+		Path:    name,
+		Started: time.Now(),
+	}
+
+	res.RoomID, _ = ctx.Value(roomIDKey{}).(uint64)
+	res.ServerID, _ = ctx.Value(serverIDKey{}).(uint64)
+
+	if parent := IntervalScopeFromContext(ctx); parent != nil {
+		res.parent = parent
+		res.ParentID = parent.ID
+		res.Path = parent.Path + IntervalPathSeparator + name
+	}
+
+	return res
+}
+
+// finish adds duration to parent. Self duration is 0 if children were running concurrently.
+func (s *IntervalScope) finish() (time.Duration, time.Duration) {
+	duration := time.Since(s.Started)
+
+	if s.parent != nil {
+		atomic.AddInt64(&s.parent.children, int64(duration))
+	}
+
+	self := duration - time.Duration(atomic.LoadInt64(&s.children))
+	if self < 0 {
+		self = 0
+	}
+
+	return duration, self
+}
+
+// startContext starts scope with full name. Result of start is called on end.
+func startContext(
+	ctx context.Context,
+	name string,
+	start func(scope *IntervalScope) func(duration, self time.Duration),
+) (context.Context, Interval) {
+	scope := newIntervalScope(ctx, name)
+	end := start(scope)
+
+	return context.WithValue(ctx, intervalScopeKey{}, scope), func() {
+		end(scope.finish())
+	}
+}
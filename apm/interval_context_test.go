@@ -0,0 +1,124 @@
+package apm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntervalScope(t *testing.T) {
+	t.Parallel()
+
+	ctx := ContextWithServerID(ContextWithRoomID(context.Background(), 7), 3)
+
+	parentCtx, parent := StartContext(ctx, NewHistogramInterval(NewIntervalRecorder(), "a."), "Parent")
+	parentScope := IntervalScopeFromContext(parentCtx)
+
+	childCtx, child := StartContext(parentCtx, NewHistogramInterval(NewIntervalRecorder(), "b."), "Child")
+	childScope := IntervalScopeFromContext(childCtx)
+
+	time.Sleep(10 * time.Millisecond)
+	child.End()
+	parent.End()
+
+	assert.Zero(t, parentScope.ParentID)
+	assert.Equal(t, "a.Parent", parentScope.Path)
+	assert.Equal(t, parentScope.ID, childScope.ParentID)
+	assert.Equal(t, "a.Parent/b.Child", childScope.Path)
+	assert.Equal(t, uint64(7), childScope.RoomID)
+	assert.Equal(t, uint64(3), childScope.ServerID)
+	assert.GreaterOrEqual(t, time.Duration(parentScope.children), 10*time.Millisecond)
+	assert.Nil(t, IntervalScopeFromContext(ctx))
+}
+
+func TestZerologInterval_StartContext(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	logger := zerolog.New(&buf).Level(zerolog.DebugLevel)
+	intervals := ZerologIntervals(&logger)
+
+	ctx, parent := StartContext(ContextWithRoomID(context.Background(), 7), intervals("a."), "Parent")
+	_, child := StartContext(ctx, intervals("b."), "Child")
+
+	child.End()
+	parent.End()
+
+	var lines []map[string]interface{}
+
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var line map[string]interface{}
+
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+
+		lines = append(lines, line)
+	}
+
+	require.Len(t, lines, 4)
+	assert.Equal(t, "start", lines[1]["message"])
+	assert.Equal(t, "a.Parent/b.Child", lines[1]["path"])
+	assert.Equal(t, lines[0]["id"], lines[1]["parent"])
+	assert.Equal(t, float64(7), lines[1]["room"])
+	assert.NotContains(t, lines[1], "server")
+	assert.Equal(t, "end", lines[3]["message"])
+	assert.Equal(t, "a.Parent", lines[3]["path"])
+	assert.Contains(t, lines[3], "duration")
+	assert.Contains(t, lines[3], "self")
+}
+
+func TestMultiInterval_StartContext(t *testing.T) {
+	t.Parallel()
+
+	recorder := NewIntervalRecorder()
+	intervals := MultiIntervals(HistogramIntervals(recorder), HistogramIntervals(recorder))
+
+	ctx, parent := StartContext(context.Background(), intervals("test."), "Parent")
+	_, child := StartContext(ctx, intervals("test."), "Child")
+
+	child.End()
+	parent.End()
+
+	stats := recorder.Stats()
+	require.Len(t, stats, 2)
+	assert.Equal(t, "test.Parent", stats[0].Name)
+	assert.Equal(t, uint64(2), stats[0].Count)
+	assert.Equal(t, "test.Parent/test.Child", stats[1].Name)
+	assert.Equal(t, uint64(2), stats[1].Count)
+}
+
+type plainInterval []string
+
+func (p *plainInterval) Start(name string) Interval {
+	*p = append(*p, name)
+
+	return nil
+}
+
+// TestStartContext_Plain checks fallback to Start for interval without StartContext.
+func TestStartContext_Plain(t *testing.T) {
+	t.Parallel()
+
+	var started plainInterval
+
+	ctx := context.Background()
+
+	res, interval := StartContext(ctx, &started, "Method")
+	interval.End()
+
+	assert.Equal(t, ctx, res)
+	assert.Equal(t, plainInterval{"Method"}, started)
+
+	_, interval = StartContext(ctx, NewMultiInterval(&started), "Multi")
+	interval.End()
+
+	assert.Equal(t, plainInterval{"Method", "Multi"}, started)
+}
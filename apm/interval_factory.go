@@ -0,0 +1,105 @@
+package apm
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// IntervalFactory creates DebuggableInterval for names with prefix.
+type IntervalFactory func(prefix string) DebuggableInterval
+
+// ZerologIntervals logs intervals with logger.
+func ZerologIntervals(logger *zerolog.Logger) IntervalFactory {
+	return func(prefix string) DebuggableInterval {
+		return NewZerologInterval(logger, prefix)
+	}
+}
+
+// SampledZerologIntervals logs intervals selected by sampler with logger.
+func SampledZerologIntervals(logger *zerolog.Logger, sampler Sampler) IntervalFactory {
+	return func(prefix string) DebuggableInterval {
+		return NewSampledZerologInterval(logger, prefix, sampler)
+	}
+}
+
+// HistogramIntervals records intervals into recorder.
+func HistogramIntervals(recorder *IntervalRecorder) IntervalFactory {
+	return func(prefix string) DebuggableInterval {
+		return NewHistogramInterval(recorder, prefix)
+	}
+}
+
+// MultiIntervals combines intervals of all factories.
+func MultiIntervals(factories ...IntervalFactory) IntervalFactory {
+	return func(prefix string) DebuggableInterval {
+		intervals := make([]DebuggableInterval, len(factories))
+
+		for i, factory := range factories {
+			intervals[i] = factory(prefix)
+		}
+
+		return NewMultiInterval(intervals...)
+	}
+}
+
+var _ ContextInterval = (intervalMulti)(nil)
+
+type intervalMulti []DebuggableInterval
+
+// NewMultiInterval starts and ends all intervals together.
+func NewMultiInterval(intervals ...DebuggableInterval) DebuggableInterval {
+	return intervalMulti(intervals)
+}
+
+func (m intervalMulti) Start(name string) Interval {
+	started := make([]Interval, len(m))
+
+	for i, interval := range m {
+		started[i] = interval.Start(name)
+	}
+
+	return func() {
+		for i := len(started) - 1; i >= 0; i-- {
+			started[i].End()
+		}
+	}
+}
+
+// StartContext starts all intervals with one shared scope.
+func (m intervalMulti) StartContext(ctx context.Context, name string) (context.Context, Interval) {
+	scope := newIntervalScope(ctx, m.name(name))
+	scopeCtx := context.WithValue(ctx, intervalScopeKey{}, scope)
+	ends := make([]func(duration, self time.Duration), len(m))
+
+	for i, interval := range m {
+		if scoped, ok := interval.(scopedInterval); ok {
+			ends[i] = scoped.startScope(scope)
+
+			continue
+		}
+
+		_, started := StartContext(ctx, interval, name)
+		ends[i] = func(time.Duration, time.Duration) { started.End() }
+	}
+
+	return scopeCtx, func() {
+		duration, self := scope.finish()
+
+		for i := len(ends) - 1; i >= 0; i-- {
+			ends[i](duration, self)
+		}
+	}
+}
+
+// name returns full name of the first interval of this package.
+func (m intervalMulti) name(name string) string {
+	for _, interval := range m {
+		if scoped, ok := interval.(scopedInterval); ok {
+			return scoped.name(name)
+		}
+	}
+
+	return name
+}
@@ -0,0 +1,277 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	latencyMin           = time.Microsecond // latencyMin is upper bound of the first bucket.
+	latencyBucketsPerBit = 8                // latencyBucketsPerBit gives ~9% precision.
+	latencyBuckets       = 36*latencyBucketsPerBit + 1
+)
+
+var (
+	_ ContextInterval = (*intervalHistogram)(nil)
+	_ scopedInterval  = (*intervalHistogram)(nil)
+)
+
+// IntervalStats is latency summary of named interval.
+type IntervalStats struct {
+	Name  string        `json:"name"`
+	Count uint64        `json:"count"`
+	Sum   time.Duration `json:"sum"`
+	Min   time.Duration `json:"min"`
+	Max   time.Duration `json:"max"`
+	P50   time.Duration `json:"p50"`
+	P95   time.Duration `json:"p95"`
+	P99   time.Duration `json:"p99"`
+}
+
+// latencyHistogram keeps durations in log-linear buckets. Safe for concurrent use.
+type latencyHistogram struct {
+	counts [latencyBuckets]uint64
+	count  uint64
+	sum    int64
+	min    int64
+	max    int64
+}
+
+func newLatencyHistogram() *latencyHistogram {
+	return &latencyHistogram{
+		min: math.MaxInt64,
+	}
+}
+
+func latencyBucket(duration time.Duration) int {
+	if duration <= latencyMin {
+		return 0
+	}
+
+	res := int(math.Ceil(math.Log2(float64(duration)/float64(latencyMin)) * latencyBucketsPerBit))
+	if res >= latencyBuckets {
+		return latencyBuckets - 1
+	}
+
+	return res
+}
+
+// latencyBound returns upper bound of bucket.
+func latencyBound(bucket int) time.Duration {
+	return time.Duration(float64(latencyMin) * math.Exp2(float64(bucket)/latencyBucketsPerBit))
+}
+
+func (h *latencyHistogram) observe(duration time.Duration) {
+	atomic.AddUint64(&h.counts[latencyBucket(duration)], 1)
+	atomic.AddUint64(&h.count, 1)
+	atomic.AddInt64(&h.sum, int64(duration))
+
+	for {
+		old := atomic.LoadInt64(&h.min)
+		if int64(duration) >= old || atomic.CompareAndSwapInt64(&h.min, old, int64(duration)) {
+			break
+		}
+	}
+
+	for {
+		old := atomic.LoadInt64(&h.max)
+		if int64(duration) <= old || atomic.CompareAndSwapInt64(&h.max, old, int64(duration)) {
+			break
+		}
+	}
+}
+
+func (h *latencyHistogram) stats(name string) IntervalStats {
+	var counts [latencyBuckets]uint64
+
+	total := uint64(0)
+
+	for i := range counts {
+		counts[i] = atomic.LoadUint64(&h.counts[i])
+		total += counts[i]
+	}
+
+	res := IntervalStats{
+		Name:  name,
+		Count: total,
+		Sum:   time.Duration(atomic.LoadInt64(&h.sum)),
+	}
+
+	if total == 0 {
+		return res
+	}
+
+	res.Min = time.Duration(atomic.LoadInt64(&h.min))
+	res.Max = time.Duration(atomic.LoadInt64(&h.max))
+	res.P50 = res.quantile(&counts, total, 0.50)
+	res.P95 = res.quantile(&counts, total, 0.95)
+	res.P99 = res.quantile(&counts, total, 0.99)
+
+	return res
+}
+
+// quantile returns upper bound of bucket with quantile limited by observed min and max.
+func (s *IntervalStats) quantile(counts *[latencyBuckets]uint64, total uint64, quantile float64) time.Duration {
+	rank := uint64(math.Ceil(quantile * float64(total)))
+	seen := uint64(0)
+
+	for bucket, count := range counts {
+		seen += count
+		if seen < rank {
+			continue
+		}
+
+		res := latencyBound(bucket)
+
+		switch {
+		case res > s.Max:
+			return s.Max
+		case res < s.Min:
+			return s.Min
+		default:
+			return res
+		}
+	}
+
+	return s.Max
+}
+
+// IntervalRecorder collects durations of named intervals into latency histograms. Safe for concurrent use.
+type IntervalRecorder struct {
+	histograms map[string]*latencyHistogram
+	mu         sync.RWMutex
+}
+
+func NewIntervalRecorder() *IntervalRecorder {
+	return &IntervalRecorder{
+		histograms: map[string]*latencyHistogram{},
+	}
+}
+
+func (r *IntervalRecorder) histogram(name string) *latencyHistogram {
+	r.mu.RLock()
+	res, ok := r.histograms[name]
+	r.mu.RUnlock()
+
+	if ok {
+		return res
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if res, ok := r.histograms[name]; ok {
+		return res
+	}
+
+	res = newLatencyHistogram()
+	r.histograms[name] = res
+
+	return res
+}
+
+// Observe records duration of named interval.
+func (r *IntervalRecorder) Observe(name string, duration time.Duration) {
+	r.histogram(name).observe(duration)
+}
+
+// Stats returns summaries of all intervals ordered by name.
+func (r *IntervalRecorder) Stats() []IntervalStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	res := make([]IntervalStats, 0, len(r.histograms))
+
+	for name, histogram := range r.histograms {
+		res = append(res, histogram.stats(name))
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+
+	return res
+}
+
+// Stat returns summary of interval. Returns false if interval wasn't observed.
+func (r *IntervalRecorder) Stat(name string) (IntervalStats, bool) {
+	r.mu.RLock()
+	histogram, ok := r.histograms[name]
+	r.mu.RUnlock()
+
+	if !ok {
+		return IntervalStats{}, false
+	}
+
+	return histogram.stats(name), true
+}
+
+// Reset removes all observations.
+func (r *IntervalRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.histograms = map[string]*latencyHistogram{}
+}
+
+// WriteJSON dumps Stats as JSON array.
+func (r *IntervalRecorder) WriteJSON(writer io.Writer) error {
+	return errors.WithStack(json.NewEncoder(writer).Encode(r.Stats()))
+}
+
+// Handler returns HTTP handler of Stats in JSON.
+func (r *IntervalRecorder) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		_ = r.WriteJSON(w)
+	})
+}
+
+type intervalHistogram struct {
+	recorder *IntervalRecorder
+	prefix   string
+}
+
+// NewHistogramInterval creates DebuggableInterval recording durations into recorder.
+func NewHistogramInterval(recorder *IntervalRecorder, prefix string) DebuggableInterval {
+	return &intervalHistogram{
+		recorder: recorder,
+		prefix:   prefix,
+	}
+}
+
+func (i *intervalHistogram) Start(name string) Interval {
+	histogram := i.recorder.histogram(i.prefix + name)
+	started := time.Now()
+
+	return func() {
+		histogram.observe(time.Since(started))
+	}
+}
+
+// StartContext records duration by path of nested interval, e.g. "a.Parent/a.Child".
+func (i *intervalHistogram) StartContext(ctx context.Context, name string) (context.Context, Interval) {
+	return startContext(ctx, i.name(name), i.startScope)
+}
+
+func (i *intervalHistogram) name(name string) string {
+	return i.prefix + name
+}
+
+func (i *intervalHistogram) startScope(scope *IntervalScope) func(duration, self time.Duration) {
+	histogram := i.recorder.histogram(scope.Path)
+
+	return func(duration, _ time.Duration) {
+		histogram.observe(duration)
+	}
+}
@@ -0,0 +1,64 @@
+package apm
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntervalRecorder(t *testing.T) {
+	t.Parallel()
+
+	recorder := NewIntervalRecorder()
+
+	for i := 1; i <= 100; i++ {
+		recorder.Observe("test.Method", time.Duration(i)*time.Millisecond)
+	}
+
+	stats, ok := recorder.Stat("test.Method")
+	require.True(t, ok)
+
+	assert.Equal(t, uint64(100), stats.Count)
+	assert.Equal(t, 5050*time.Millisecond, stats.Sum)
+	assert.Equal(t, time.Millisecond, stats.Min)
+	assert.Equal(t, 100*time.Millisecond, stats.Max)
+	assert.InEpsilon(t, float64(50*time.Millisecond), float64(stats.P50), 0.1)
+	assert.InEpsilon(t, float64(95*time.Millisecond), float64(stats.P95), 0.1)
+	assert.InEpsilon(t, float64(99*time.Millisecond), float64(stats.P99), 0.1)
+
+	_, ok = recorder.Stat("unknown")
+	assert.False(t, ok)
+
+	var buf bytes.Buffer
+
+	require.NoError(t, recorder.WriteJSON(&buf))
+
+	var dump []IntervalStats
+
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &dump))
+	assert.Equal(t, []IntervalStats{stats}, dump)
+
+	recorder.Reset()
+	assert.Empty(t, recorder.Stats())
+}
+
+func TestHistogramInterval(t *testing.T) {
+	t.Parallel()
+
+	recorder := NewIntervalRecorder()
+	intervals := MultiIntervals(HistogramIntervals(recorder), HistogramIntervals(recorder))
+
+	interval := intervals("test.")
+	interval.Start("B").End()
+	interval.Start("A").End()
+
+	stats := recorder.Stats()
+	require.Len(t, stats, 2)
+	assert.Equal(t, "test.A", stats[0].Name)
+	assert.Equal(t, uint64(2), stats[0].Count)
+	assert.Equal(t, "test.B", stats[1].Name)
+}
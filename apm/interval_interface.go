@@ -1,9 +1,28 @@
 package apm
 
+import "context"
+
 type DebuggableInterval interface {
 	Start(name string) Interval
 }
 
+// ContextInterval is optional DebuggableInterval extension for nested intervals.
+type ContextInterval interface {
+	DebuggableInterval
+	// StartContext starts child of interval from ctx. Returned ctx contains started interval.
+	StartContext(ctx context.Context, name string) (context.Context, Interval)
+}
+
+// StartContext starts child of interval from ctx if interval implements ContextInterval.
+// Otherwise interval is started with Start and ctx is returned unchanged.
+func StartContext(ctx context.Context, interval DebuggableInterval, name string) (context.Context, Interval) {
+	if nested, ok := interval.(ContextInterval); ok {
+		return nested.StartContext(ctx, name)
+	}
+
+	return ctx, interval.Start(name)
+}
+
 type Interval func()
 
 func (i Interval) End() {
@@ -0,0 +1,144 @@
+package apm
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Sampler selects logged intervals. Implementations must be safe for concurrent use.
+type Sampler interface {
+	// Start is called on interval start. Interval is skipped if false.
+	Start() bool
+	// End is called with duration of started interval. Interval is logged if true.
+	End(duration time.Duration) bool
+}
+
+var (
+	_ Sampler = (*samplerEvery)(nil)
+	_ Sampler = (samplerProbability)(0)
+	_ Sampler = (samplerSlow)(0)
+	_ Sampler = (*samplerRate)(nil)
+	_ Sampler = (samplerCombined)(nil)
+)
+
+type samplerEvery struct {
+	n     uint64
+	count uint64 // count of started intervals, atomic.
+}
+
+// SampleEvery logs the first of every n intervals. Every interval is logged if n <= 1.
+func SampleEvery(n uint64) Sampler {
+	if n == 0 {
+		n = 1
+	}
+
+	return &samplerEvery{
+		n: n,
+	}
+}
+
+func (s *samplerEvery) Start() bool {
+	return (atomic.AddUint64(&s.count, 1)-1)%s.n == 0
+}
+
+func (s *samplerEvery) End(time.Duration) bool {
+	return true
+}
+
+type samplerProbability float64
+
+// SampleProbability logs interval with probability in range [0, 1].
+func SampleProbability(probability float64) Sampler {
+	return samplerProbability(probability)
+}
+
+func (s samplerProbability) Start() bool {
+	//nolint:gosec // sampling doesn't require crypto random.
+	return rand.Float64() < float64(s)
+}
+
+func (s samplerProbability) End(time.Duration) bool {
+	return true
+}
+
+type samplerSlow time.Duration
+
+// SampleSlow logs intervals not faster than threshold.
+func SampleSlow(threshold time.Duration) Sampler {
+	return samplerSlow(threshold)
+}
+
+func (s samplerSlow) Start() bool {
+	return true
+}
+
+func (s samplerSlow) End(duration time.Duration) bool {
+	return duration >= time.Duration(s)
+}
+
+type samplerRate struct {
+	limit  uint64
+	window int64  // window is unix second of current window.
+	count  uint64 // count of logged intervals in current window.
+	mu     sync.Mutex
+}
+
+// SampleRate logs at most limit intervals per second.
+func SampleRate(limit uint64) Sampler {
+	return &samplerRate{
+		limit: limit,
+	}
+}
+
+func (s *samplerRate) Start() bool {
+	return true
+}
+
+func (s *samplerRate) End(time.Duration) bool {
+	now := time.Now().Unix()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.window != now {
+		s.window = now
+		s.count = 0
+	}
+
+	if s.count >= s.limit {
+		return false
+	}
+
+	s.count++
+
+	return true
+}
+
+type samplerCombined []Sampler
+
+// CombineSamplers logs interval selected by all samplers, e.g. slow intervals with rate limit.
+func CombineSamplers(samplers ...Sampler) Sampler {
+	return samplerCombined(samplers)
+}
+
+func (s samplerCombined) Start() bool {
+	for _, sampler := range s {
+		if !sampler.Start() {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s samplerCombined) End(duration time.Duration) bool {
+	for _, sampler := range s {
+		if !sampler.End(duration) {
+			return false
+		}
+	}
+
+	return true
+}
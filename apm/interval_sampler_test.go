@@ -0,0 +1,167 @@
+package apm
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSampleEvery(t *testing.T) {
+	t.Parallel()
+
+	sampler := SampleEvery(3)
+
+	var res []bool
+
+	for i := 0; i < 6; i++ {
+		res = append(res, sampler.Start())
+	}
+
+	assert.Equal(t, []bool{true, false, false, true, false, false}, res)
+	assert.True(t, SampleEvery(0).Start())
+}
+
+func TestSampleProbability(t *testing.T) {
+	t.Parallel()
+
+	assert.False(t, SampleProbability(0).Start())
+	assert.True(t, SampleProbability(1).Start())
+}
+
+func TestSampleSlow(t *testing.T) {
+	t.Parallel()
+
+	sampler := SampleSlow(time.Millisecond)
+
+	assert.True(t, sampler.Start())
+	assert.False(t, sampler.End(time.Microsecond))
+	assert.True(t, sampler.End(time.Millisecond))
+}
+
+func TestSampleRate(t *testing.T) {
+	t.Parallel()
+
+	sampler := CombineSamplers(SampleSlow(time.Millisecond), SampleRate(2))
+
+	assert.True(t, sampler.Start())
+	assert.False(t, sampler.End(0))
+	assert.True(t, sampler.End(time.Second))
+	assert.True(t, sampler.End(time.Second))
+	assert.False(t, sampler.End(time.Second))
+}
+
+// TestSampleRate_Concurrent checks limit under concurrent use, run with -race.
+func TestSampleRate_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	sampler := SampleRate(10)
+	start := time.Now().Unix()
+
+	var (
+		logged uint64
+		wg     sync.WaitGroup
+	)
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				if sampler.End(0) {
+					atomic.AddUint64(&logged, 1)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	windows := uint64(time.Now().Unix()-start) + 1
+	assert.LessOrEqual(t, logged, 10*windows)
+	assert.GreaterOrEqual(t, logged, uint64(10))
+}
+
+func TestSampledZerologInterval(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	logger := zerolog.New(&buf).Level(zerolog.DebugLevel)
+	interval := NewSampledZerologInterval(&logger, "test.", SampleEvery(2))
+
+	interval.Start("A").End()
+	interval.Start("B").End()
+
+	_, started := StartContext(context.Background(), interval, "C")
+	started.End()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"name":"test.A"`)
+	assert.Contains(t, lines[0], `"duration"`)
+	assert.Contains(t, lines[1], `"path":"test.C"`)
+}
+
+func TestZerologInterval_Disabled(t *testing.T) {
+	logger := zerolog.New(io.Discard).Level(zerolog.InfoLevel)
+	interval := NewZerologInterval(&logger, "test.")
+	ctx := context.Background()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		interval.Start("A").End()
+
+		_, started := StartContext(ctx, interval, "B")
+		started.End()
+	})
+
+	assert.Zero(t, allocs)
+}
+
+// BenchmarkInterval shows cost of measuring one call.
+func BenchmarkInterval(b *testing.B) {
+	debug := zerolog.New(io.Discard).Level(zerolog.DebugLevel)
+	info := zerolog.New(io.Discard).Level(zerolog.InfoLevel)
+
+	for _, bench := range []struct {
+		name     string
+		interval DebuggableInterval
+	}{
+		{"zerolog/disabled", NewZerologInterval(&info, "bench.")},
+		{"zerolog/all", NewZerologInterval(&debug, "bench.")},
+		{"zerolog/every-100", NewSampledZerologInterval(&debug, "bench.", SampleEvery(100))},
+		{"zerolog/probability-0.01", NewSampledZerologInterval(&debug, "bench.", SampleProbability(0.01))},
+		{"zerolog/slow-1ms", NewSampledZerologInterval(&debug, "bench.", SampleSlow(time.Millisecond))},
+		{"histogram", NewHistogramInterval(NewIntervalRecorder(), "bench.")},
+	} {
+		bench := bench
+
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				bench.interval.Start("Method").End()
+			}
+		})
+
+		b.Run(bench.name+"/context", func(b *testing.B) {
+			ctx := context.Background()
+
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				_, started := StartContext(ctx, bench.interval, "Method")
+				started.End()
+			}
+		})
+	}
+}
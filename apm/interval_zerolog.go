@@ -1,19 +1,25 @@
 package apm
 
 import (
+	"context"
 	"sync/atomic"
+	"time"
 
 	"github.com/rs/zerolog"
 )
 
-var _ DebuggableInterval = (*intervalZerolog)(nil)
+var (
+	_ ContextInterval = (*intervalZerolog)(nil)
+	_ scopedInterval  = (*intervalZerolog)(nil)
+)
 
 //noling:gochecknoglobals // required for debug.
 var id uint64
 
 type intervalZerolog struct {
-	logger *zerolog.Logger
-	prefix string
+	logger  *zerolog.Logger
+	prefix  string
+	sampler Sampler // sampler is nil if every interval is logged on start and end.
 }
 
 func NewZerologInterval(logger *zerolog.Logger, prefix string) DebuggableInterval {
@@ -23,7 +29,29 @@ func NewZerologInterval(logger *zerolog.Logger, prefix string) DebuggableInterva
 	}
 }
 
+// NewSampledZerologInterval logs intervals selected by sampler with one line on end.
+func NewSampledZerologInterval(logger *zerolog.Logger, prefix string, sampler Sampler) DebuggableInterval {
+	return &intervalZerolog{
+		logger:  logger,
+		prefix:  prefix,
+		sampler: sampler,
+	}
+}
+
+// enabled returns false if debug level is disabled, intervals aren't measured then.
+func (i *intervalZerolog) enabled() bool {
+	return i.logger.GetLevel() <= zerolog.DebugLevel && zerolog.GlobalLevel() <= zerolog.DebugLevel
+}
+
 func (i *intervalZerolog) Start(name string) Interval {
+	if !i.enabled() {
+		return nil
+	}
+
+	if i.sampler != nil {
+		return i.startSampled(name)
+	}
+
 	logger := i.logger.With().
 		Str("name", i.prefix+name).
 		Uint64("id", atomic.AddUint64(&id, 1)).
@@ -34,3 +62,93 @@ func (i *intervalZerolog) Start(name string) Interval {
 		logger.Debug().Msg("end")
 	}
 }
+
+func (i *intervalZerolog) startSampled(name string) Interval {
+	if !i.sampler.Start() {
+		return nil
+	}
+
+	started := time.Now()
+
+	return func() {
+		duration := time.Since(started)
+
+		if i.sampler.End(duration) {
+			i.logger.Debug().
+				Str("name", i.prefix+name).
+				Uint64("id", atomic.AddUint64(&id, 1)).
+				Dur("duration", duration).
+				Msg("end")
+		}
+	}
+}
+
+// StartContext logs interval with parent id, path, room and server id. End contains duration and self duration.
+// Skipped intervals aren't added to ctx, so path of children contains only sampled parents.
+func (i *intervalZerolog) StartContext(ctx context.Context, name string) (context.Context, Interval) {
+	if !i.enabled() || (i.sampler != nil && !i.sampler.Start()) {
+		return ctx, nil
+	}
+
+	return startContext(ctx, i.name(name), i.logScope)
+}
+
+func (i *intervalZerolog) name(name string) string {
+	return i.prefix + name
+}
+
+func (i *intervalZerolog) startScope(scope *IntervalScope) func(duration, self time.Duration) {
+	if !i.enabled() || (i.sampler != nil && !i.sampler.Start()) {
+		return skipScope
+	}
+
+	return i.logScope(scope)
+}
+
+// logScope logs started scope, sampler is checked only on end.
+func (i *intervalZerolog) logScope(scope *IntervalScope) func(duration, self time.Duration) {
+	if i.sampler != nil {
+		return func(duration, self time.Duration) {
+			if i.sampler.End(duration) {
+				logger := i.scopeLogger(scope)
+				logger.Debug().
+					Dur("duration", duration).
+					Dur("self", self).
+					Msg("end")
+			}
+		}
+	}
+
+	logger := i.scopeLogger(scope)
+	logger.Debug().Msg("start")
+
+	return func(duration, self time.Duration) {
+		logger.Debug().
+			Dur("duration", duration).
+			Dur("self", self).
+			Msg("end")
+	}
+}
+
+func (i *intervalZerolog) scopeLogger(scope *IntervalScope) zerolog.Logger {
+	fields := i.logger.With().
+		Str("name", scope.Name).
+		Str("path", scope.Path).
+		Uint64("id", scope.ID)
+
+	if scope.ParentID != 0 {
+		fields = fields.Uint64("parent", scope.ParentID)
+	}
+
+	if scope.RoomID != 0 {
+		fields = fields.Uint64("room", scope.RoomID)
+	}
+
+	if scope.ServerID != 0 {
+		fields = fields.Uint64("server", scope.ServerID)
+	}
+
+	return fields.Logger()
+}
+
+func skipScope(time.Duration, time.Duration) {}
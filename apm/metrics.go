@@ -0,0 +1,133 @@
+package apm
+
+import (
+	"math"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultBuckets are histogram buckets in seconds suitable for network latencies.
+//
+//nolint:gochecknoglobals // read-only.
+var DefaultBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type atomicFloat struct {
+	bits uint64
+}
+
+func (f *atomicFloat) Add(value float64) {
+	for {
+		old := atomic.LoadUint64(&f.bits)
+		next := math.Float64bits(math.Float64frombits(old) + value)
+
+		if atomic.CompareAndSwapUint64(&f.bits, old, next) {
+			return
+		}
+	}
+}
+
+func (f *atomicFloat) Set(value float64) {
+	atomic.StoreUint64(&f.bits, math.Float64bits(value))
+}
+
+func (f *atomicFloat) Load() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&f.bits))
+}
+
+// Counter is monotonically increasing value. Safe for concurrent use.
+type Counter struct {
+	value atomicFloat
+}
+
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+// Add increases counter, negative values are ignored.
+func (c *Counter) Add(value float64) {
+	if value > 0 {
+		c.value.Add(value)
+	}
+}
+
+func (c *Counter) Value() float64 {
+	return c.value.Load()
+}
+
+// Gauge is arbitrary value. Safe for concurrent use.
+type Gauge struct {
+	value atomicFloat
+}
+
+func (g *Gauge) Set(value float64) {
+	g.value.Set(value)
+}
+
+func (g *Gauge) Add(value float64) {
+	g.value.Add(value)
+}
+
+func (g *Gauge) Inc() {
+	g.value.Add(1)
+}
+
+func (g *Gauge) Dec() {
+	g.value.Add(-1)
+}
+
+func (g *Gauge) Value() float64 {
+	return g.value.Load()
+}
+
+// Histogram counts observations in cumulative buckets. Safe for concurrent use.
+type Histogram struct {
+	buckets []float64 // buckets are sorted upper bounds.
+	counts  []uint64  // counts are non-cumulative, last one is +Inf.
+	sum     atomicFloat
+	count   uint64
+}
+
+func newHistogram(buckets []float64) *Histogram {
+	return &Histogram{
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)+1),
+	}
+}
+
+func (h *Histogram) Observe(value float64) {
+	index := sort.SearchFloat64s(h.buckets, value)
+
+	atomic.AddUint64(&h.counts[index], 1)
+	atomic.AddUint64(&h.count, 1)
+	h.sum.Add(value)
+}
+
+// ObserveDuration observes duration in seconds.
+func (h *Histogram) ObserveDuration(duration time.Duration) {
+	h.Observe(duration.Seconds())
+}
+
+// Count returns count of observations.
+func (h *Histogram) Count() uint64 {
+	return atomic.LoadUint64(&h.count)
+}
+
+// Sum returns sum of observations.
+func (h *Histogram) Sum() float64 {
+	return h.sum.Load()
+}
+
+// cumulative returns cumulative counts of buckets with +Inf.
+func (h *Histogram) cumulative() []uint64 {
+	res := make([]uint64, len(h.counts))
+
+	var total uint64
+
+	for i := range h.counts {
+		total += atomic.LoadUint64(&h.counts[i])
+		res[i] = total
+	}
+
+	return res
+}
@@ -0,0 +1,372 @@
+package apm
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/pkg/errors"
+	"golang.org/x/exp/slices"
+)
+
+type Kind string
+
+const (
+	KindCounter   Kind = "counter"
+	KindGauge     Kind = "gauge"
+	KindHistogram Kind = "histogram"
+)
+
+const ContentTypeText = "text/plain; version=0.0.4; charset=utf-8"
+
+// Sample is value of GaugeFunc with label values in order of label names.
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+type series struct {
+	labelValues []string
+	counter     *Counter
+	gauge       *Gauge
+	histogram   *Histogram
+}
+
+type family struct {
+	name    string
+	help    string
+	kind    Kind
+	labels  []string
+	buckets []float64
+	fn      func() []Sample // fn is set for GaugeFunc.
+	series  map[string]*series
+	mu      sync.Mutex
+}
+
+// with returns series of label values, missing values are empty.
+func (f *family) with(values []string) *series {
+	if len(values) != len(f.labels) {
+		fixed := make([]string, len(f.labels))
+		copy(fixed, values)
+		values = fixed
+	}
+
+	key := strings.Join(values, "\xff")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if res, ok := f.series[key]; ok {
+		return res
+	}
+
+	res := &series{
+		labelValues: slices.Clone(values),
+	}
+
+	switch f.kind {
+	case KindCounter:
+		res.counter = &Counter{}
+	case KindGauge:
+		res.gauge = &Gauge{}
+	case KindHistogram:
+		res.histogram = newHistogram(f.buckets)
+	}
+
+	f.series[key] = res
+
+	return res
+}
+
+func (f *family) delete(values []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.series, strings.Join(values, "\xff"))
+}
+
+type CounterVec struct {
+	family *family
+}
+
+// With returns counter of label values in order of label names.
+func (v *CounterVec) With(values ...string) *Counter {
+	return v.family.with(values).counter
+}
+
+type GaugeVec struct {
+	family *family
+}
+
+// With returns gauge of label values in order of label names.
+func (v *GaugeVec) With(values ...string) *Gauge {
+	return v.family.with(values).gauge
+}
+
+// Delete removes gauge of label values.
+func (v *GaugeVec) Delete(values ...string) {
+	v.family.delete(values)
+}
+
+type HistogramVec struct {
+	family *family
+}
+
+// With returns histogram of label values in order of label names.
+func (v *HistogramVec) With(values ...string) *Histogram {
+	return v.family.with(values).histogram
+}
+
+// Registry keeps metrics and writes them in Prometheus text exposition format. Safe for concurrent use.
+// Metric registered again with the same name is shared, registration with other kind or labels panics.
+type Registry struct {
+	families map[string]*family
+	mu       sync.RWMutex
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		families: map[string]*family{},
+	}
+}
+
+func (r *Registry) family(name string, help string, kind Kind, labels []string, buckets []float64) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if res, ok := r.families[name]; ok {
+		if res.kind != kind || !slices.Equal(res.labels, labels) {
+			panic(errors.Wrapf(constants.ErrInvalid, "metric %s is registered as %s with labels %v", name, res.kind, res.labels))
+		}
+
+		return res
+	}
+
+	if kind == KindHistogram && len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+
+	res := &family{
+		name:    name,
+		help:    help,
+		kind:    kind,
+		labels:  labels,
+		buckets: buckets,
+		series:  map[string]*series{},
+	}
+
+	r.families[name] = res
+
+	return res
+}
+
+func (r *Registry) Counter(name string, help string) *Counter {
+	return r.CounterVec(name, help).With()
+}
+
+func (r *Registry) CounterVec(name string, help string, labels ...string) *CounterVec {
+	return &CounterVec{
+		family: r.family(name, help, KindCounter, labels, nil),
+	}
+}
+
+func (r *Registry) Gauge(name string, help string) *Gauge {
+	return r.GaugeVec(name, help).With()
+}
+
+func (r *Registry) GaugeVec(name string, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{
+		family: r.family(name, help, KindGauge, labels, nil),
+	}
+}
+
+// GaugeFunc registers gauge calculated by fn on every scrape. Previous fn with the same name is replaced.
+func (r *Registry) GaugeFunc(name string, help string, labels []string, fn func() []Sample) {
+	family := r.family(name, help, KindGauge, labels, nil)
+
+	family.mu.Lock()
+	family.fn = fn
+	family.mu.Unlock()
+}
+
+// Histogram registers histogram. Default buckets = DefaultBuckets.
+func (r *Registry) Histogram(name string, help string, buckets []float64) *Histogram {
+	return r.HistogramVec(name, help, buckets).With()
+}
+
+// HistogramVec registers histogram with labels. Default buckets = DefaultBuckets.
+func (r *Registry) HistogramVec(name string, help string, buckets []float64, labels ...string) *HistogramVec {
+	return &HistogramVec{
+		family: r.family(name, help, KindHistogram, labels, buckets),
+	}
+}
+
+// WriteText writes all metrics in Prometheus text exposition format.
+func (r *Registry) WriteText(writer io.Writer) error {
+	r.mu.RLock()
+
+	families := make([]*family, 0, len(r.families))
+	for _, family := range r.families {
+		families = append(families, family)
+	}
+
+	r.mu.RUnlock()
+
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].name < families[j].name
+	})
+
+	buf := bufio.NewWriter(writer)
+
+	for _, family := range families {
+		family.write(buf)
+	}
+
+	return errors.WithStack(buf.Flush())
+}
+
+// Handler returns HTTP handler of metrics in Prometheus text exposition format.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentTypeText)
+
+		_ = r.WriteText(w)
+	})
+}
+
+func (f *family) write(buf *bufio.Writer) {
+	f.mu.Lock()
+
+	fn := f.fn
+
+	all := make([]*series, 0, len(f.series))
+	for _, series := range f.series {
+		all = append(all, series)
+	}
+
+	f.mu.Unlock()
+
+	if fn != nil {
+		for _, sample := range fn() {
+			all = append(all, &series{
+				labelValues: sample.LabelValues,
+				gauge:       gaugeOf(sample.Value),
+			})
+		}
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return slices.Compare(all[i].labelValues, all[j].labelValues) < 0
+	})
+
+	buf.WriteString("# HELP " + f.name + " " + escapeHelp(f.help) + "\n")
+	buf.WriteString("# TYPE " + f.name + " " + string(f.kind) + "\n")
+
+	for _, series := range all {
+		labels := formatLabels(f.labels, series.labelValues)
+
+		switch {
+		case series.counter != nil:
+			writeSample(buf, f.name, labels, series.counter.Value())
+		case series.gauge != nil:
+			writeSample(buf, f.name, labels, series.gauge.Value())
+		case series.histogram != nil:
+			f.writeHistogram(buf, series)
+		}
+	}
+}
+
+func (f *family) writeHistogram(buf *bufio.Writer, series *series) {
+	counts := series.histogram.cumulative()
+	names := append(slices.Clone(f.labels), "le")
+
+	for i, count := range counts {
+		bound := math.Inf(1)
+		if i < len(f.buckets) {
+			bound = f.buckets[i]
+		}
+
+		values := append(slices.Clone(series.labelValues), formatFloat(bound))
+
+		writeSample(buf, f.name+"_bucket", formatLabels(names, values), float64(count))
+	}
+
+	labels := formatLabels(f.labels, series.labelValues)
+
+	writeSample(buf, f.name+"_sum", labels, series.histogram.Sum())
+	writeSample(buf, f.name+"_count", labels, float64(counts[len(counts)-1]))
+}
+
+func gaugeOf(value float64) *Gauge {
+	res := &Gauge{}
+	res.Set(value)
+
+	return res
+}
+
+func writeSample(buf *bufio.Writer, name string, labels string, value float64) {
+	buf.WriteString(name + labels + " " + formatFloat(value) + "\n")
+}
+
+func formatLabels(names []string, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	var res strings.Builder
+
+	res.WriteByte('{')
+
+	for i, name := range names {
+		if i > 0 {
+			res.WriteByte(',')
+		}
+
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+
+		res.WriteString(name + `="` + escapeLabel(value) + `"`)
+	}
+
+	res.WriteByte('}')
+
+	return res.String()
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+//nolint:gochecknoglobals // read-only.
+var (
+	helpReplacer  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(value string) string {
+	return helpReplacer.Replace(value)
+}
+
+func escapeLabel(value string) string {
+	return labelReplacer.Replace(value)
+}
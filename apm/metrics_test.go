@@ -0,0 +1,81 @@
+package apm
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_WriteText(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+
+	registry.Counter("test_total", "Total count.").Add(3)
+	registry.CounterVec("test_total", "Total count.").With().Inc()
+
+	gauges := registry.GaugeVec("test_gauge", "Gauge with\nlabels.", "server")
+	gauges.With("2").Set(-1.5)
+	gauges.With("1").Inc()
+	gauges.With("3").Inc()
+	gauges.Delete("3")
+
+	registry.GaugeFunc("test_func", "Func.", []string{"room"}, func() []Sample {
+		return []Sample{{LabelValues: []string{`a"b`}, Value: 7}}
+	})
+
+	histogram := registry.Histogram("test_seconds", "Latency.", []float64{1, 0.1})
+	histogram.Observe(0.05)
+	histogram.Observe(0.5)
+	histogram.Observe(5)
+
+	var buf bytes.Buffer
+
+	require.NoError(t, registry.WriteText(&buf))
+	assert.Equal(t, `# HELP test_func Func.
+# TYPE test_func gauge
+test_func{room="a\"b"} 7
+# HELP test_gauge Gauge with\nlabels.
+# TYPE test_gauge gauge
+test_gauge{server="1"} 1
+test_gauge{server="2"} -1.5
+# HELP test_seconds Latency.
+# TYPE test_seconds histogram
+test_seconds_bucket{le="0.1"} 1
+test_seconds_bucket{le="1"} 2
+test_seconds_bucket{le="+Inf"} 3
+test_seconds_sum 5.55
+test_seconds_count 3
+# HELP test_total Total count.
+# TYPE test_total counter
+test_total 4
+`, buf.String())
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	registry.Gauge("test", "Test.").Set(1)
+
+	res := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, ContentTypeText, res.Header().Get("Content-Type"))
+	assert.Contains(t, res.Body.String(), "test 1\n")
+}
+
+func TestRegistry_Conflict(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	registry.Counter("test_total", "Test.").Inc()
+
+	assert.Same(t, registry.Counter("test_total", "Test."), registry.Counter("test_total", "Test."))
+	assert.Panics(t, func() { registry.Gauge("test_total", "Test.") })
+	assert.Panics(t, func() { registry.CounterVec("test_total", "Test.", "label") })
+}
@@ -0,0 +1,266 @@
+package apm
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/pkg/errors"
+)
+
+type (
+	TraceID [16]byte
+	SpanID  [8]byte
+)
+
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+// SpanContext identifies span across processes.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+}
+
+func (c SpanContext) IsValid() bool {
+	return c.TraceID.IsValid() && c.SpanID.IsValid()
+}
+
+// TraceParent returns W3C traceparent header value. Returns empty string for invalid context.
+func (c SpanContext) TraceParent() string {
+	if !c.IsValid() {
+		return ""
+	}
+
+	return "00-" + c.TraceID.String() + "-" + c.SpanID.String() + "-01"
+}
+
+// ParseTraceParent parses W3C traceparent header value.
+func ParseTraceParent(value string) (SpanContext, error) {
+	var res SpanContext
+
+	parts := strings.Split(value, "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || len(parts[3]) != 2 {
+		return res, errors.Wrapf(constants.ErrInvalid, "traceparent %q", value)
+	}
+
+	traceID, err := hex.DecodeString(parts[1])
+	if err != nil || len(traceID) != len(res.TraceID) {
+		return res, errors.Wrapf(constants.ErrInvalid, "trace id %q", parts[1])
+	}
+
+	spanID, err := hex.DecodeString(parts[2])
+	if err != nil || len(spanID) != len(res.SpanID) {
+		return res, errors.Wrapf(constants.ErrInvalid, "span id %q", parts[2])
+	}
+
+	copy(res.TraceID[:], traceID)
+	copy(res.SpanID[:], spanID)
+
+	if !res.IsValid() {
+		return res, errors.Wrapf(constants.ErrInvalid, "traceparent %q", value)
+	}
+
+	return res, nil
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpanContext returns ctx with parent span, e.g. received from another process.
+func ContextWithSpanContext(ctx context.Context, spanContext SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, spanContext)
+}
+
+// ContextWithTraceParent returns ctx with parent span from W3C traceparent. Invalid value is ignored.
+func ContextWithTraceParent(ctx context.Context, traceParent string) context.Context {
+	if traceParent == "" {
+		return ctx
+	}
+
+	spanContext, err := ParseTraceParent(traceParent)
+	if err != nil {
+		return ctx
+	}
+
+	return ContextWithSpanContext(ctx, spanContext)
+}
+
+// SpanContextFromContext returns parent span of ctx.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	res, _ := ctx.Value(spanContextKey{}).(SpanContext)
+
+	return res
+}
+
+// Tracer creates spans.
+type Tracer interface {
+	// Start creates span with parent from ctx. Returned ctx contains new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is single timed operation of trace.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	SetError(err error)
+	Context() SpanContext
+	End()
+}
+
+// SpanData is finished span.
+type SpanData struct {
+	Name       string
+	Service    string
+	TraceID    TraceID
+	SpanID     SpanID
+	ParentID   SpanID // ParentID is invalid for root span.
+	Start      time.Time
+	End        time.Time
+	Attributes map[string]string
+	Error      string
+}
+
+// SpanExporter receives finished spans.
+type SpanExporter interface {
+	ExportSpan(span *SpanData)
+}
+
+var _ Tracer = (*tracer)(nil)
+
+type tracer struct {
+	service  string
+	exporter SpanExporter
+}
+
+// NewTracer creates tracer which sends finished spans of service to exporter.
+func NewTracer(service string, exporter SpanExporter) Tracer {
+	return &tracer{
+		service:  service,
+		exporter: exporter,
+	}
+}
+
+func (t *tracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	parent := SpanContextFromContext(ctx)
+
+	res := &span{
+		tracer: t,
+		data: SpanData{
+			Name:     name,
+			Service:  t.service,
+			TraceID:  parent.TraceID,
+			ParentID: parent.SpanID,
+			Start:    time.Now(),
+		},
+	}
+
+	if !parent.IsValid() {
+		_, _ = rand.Read(res.data.TraceID[:])
+		res.data.ParentID = SpanID{}
+	}
+
+	_, _ = rand.Read(res.data.SpanID[:])
+
+	return ContextWithSpanContext(ctx, res.Context()), res
+}
+
+type span struct {
+	tracer *tracer
+	data   SpanData
+	ended  bool
+	mu     sync.Mutex
+}
+
+func (s *span) SetAttribute(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.data.Attributes == nil {
+		s.data.Attributes = map[string]string{}
+	}
+
+	s.data.Attributes[key] = fmt.Sprint(value)
+}
+
+func (s *span) SetError(err error) {
+	if err == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Error = err.Error()
+}
+
+func (s *span) Context() SpanContext {
+	return SpanContext{
+		TraceID: s.data.TraceID,
+		SpanID:  s.data.SpanID,
+	}
+}
+
+// End exports span once.
+func (s *span) End() {
+	s.mu.Lock()
+
+	if s.ended {
+		s.mu.Unlock()
+
+		return
+	}
+
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+
+	s.mu.Unlock()
+
+	s.tracer.exporter.ExportSpan(&data)
+}
+
+var _ Tracer = nopTracer{}
+
+type nopTracer struct{}
+
+// NopTracer creates no spans. Spans have context of parent from ctx to keep propagation.
+func NopTracer() Tracer {
+	return nopTracer{}
+}
+
+func (nopTracer) Start(ctx context.Context, _ string) (context.Context, Span) {
+	return ctx, nopSpan{
+		parent: SpanContextFromContext(ctx),
+	}
+}
+
+type nopSpan struct {
+	parent SpanContext
+}
+
+func (nopSpan) SetAttribute(string, interface{}) {}
+
+func (nopSpan) SetError(error) {}
+
+func (s nopSpan) Context() SpanContext {
+	return s.parent
+}
+
+func (nopSpan) End() {}
@@ -0,0 +1,56 @@
+package apm
+
+import "sync"
+
+var _ SpanExporter = (*MemoryExporter)(nil)
+
+// MemoryExporter keeps finished spans in memory, intended for tests. Safe for concurrent use.
+type MemoryExporter struct {
+	spans []*SpanData
+	mu    sync.Mutex
+}
+
+func NewMemoryExporter() *MemoryExporter {
+	return &MemoryExporter{}
+}
+
+func (e *MemoryExporter) ExportSpan(span *SpanData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.spans = append(e.spans, span)
+}
+
+// Spans returns all finished spans in order of finish.
+func (e *MemoryExporter) Spans() []*SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	res := make([]*SpanData, len(e.spans))
+	copy(res, e.spans)
+
+	return res
+}
+
+// Trace returns finished spans of trace.
+func (e *MemoryExporter) Trace(traceID TraceID) []*SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var res []*SpanData
+
+	for _, span := range e.spans {
+		if span.TraceID == traceID {
+			res = append(res, span)
+		}
+	}
+
+	return res
+}
+
+func (e *MemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.spans = nil
+}
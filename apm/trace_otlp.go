@@ -0,0 +1,319 @@
+package apm
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+const (
+	DefaultOTLPBatchSize = 512
+	DefaultOTLPInterval  = time.Second * 5
+
+	otlpScopeName      = "github.com/opoccomaxao-go/rooms"
+	otlpKindInternal   = 1
+	otlpStatusError    = 2
+	otlpQueueBatches   = 4 // otlpQueueBatches limits queued spans, newer spans are dropped.
+	otlpServiceName    = "service.name"
+	otlpContentType    = "application/json"
+	otlpUnknownService = "unknown_service"
+)
+
+type OTLPConfig struct {
+	Endpoint  string            // Endpoint is OTLP/HTTP traces URL, e.g. http://localhost:4318/v1/traces.
+	Headers   map[string]string // optional. Headers are added to every request, e.g. authorization.
+	Client    *http.Client      // optional. Default = http.Client with constants.DefaultTimeout.
+	BatchSize int               // optional. Max spans per request. Default = DefaultOTLPBatchSize
+	Interval  time.Duration     // optional. Interval between exports. Default = DefaultOTLPInterval
+
+	Logger *zerolog.Logger
+}
+
+var _ SpanExporter = (*OTLPExporter)(nil)
+
+// OTLPExporter sends spans in batches to OpenTelemetry collector with OTLP/HTTP JSON encoding.
+type OTLPExporter struct {
+	config  OTLPConfig
+	spans   []*SpanData
+	flush   chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+	mu      sync.Mutex
+}
+
+func NewOTLPExporter(cfg OTLPConfig) (*OTLPExporter, error) {
+	if cfg.Endpoint == "" {
+		return nil, errors.WithMessage(constants.ErrNoParam, "Endpoint")
+	}
+
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{
+			Timeout: constants.DefaultTimeout,
+		}
+	}
+
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultOTLPBatchSize
+	}
+
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultOTLPInterval
+	}
+
+	if cfg.Logger == nil {
+		logger := zerolog.Nop()
+		cfg.Logger = &logger
+	}
+
+	res := &OTLPExporter{
+		config:  cfg,
+		flush:   make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	go res.serve()
+
+	return res, nil
+}
+
+// ExportSpan queues span. Spans are dropped if queue is full.
+func (e *OTLPExporter) ExportSpan(span *SpanData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(e.spans) >= e.config.BatchSize*otlpQueueBatches {
+		return
+	}
+
+	e.spans = append(e.spans, span)
+
+	if len(e.spans) >= e.config.BatchSize {
+		select {
+		case e.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (e *OTLPExporter) serve() {
+	defer close(e.stopped)
+
+	ticker := time.NewTicker(e.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.done:
+			return
+		case <-ticker.C:
+		case <-e.flush:
+		}
+
+		err := e.Flush()
+		if err != nil {
+			e.config.Logger.Err(err).Stack().Send()
+		}
+	}
+}
+
+// Flush sends all queued spans.
+func (e *OTLPExporter) Flush() error {
+	for {
+		e.mu.Lock()
+
+		size := len(e.spans)
+		if size > e.config.BatchSize {
+			size = e.config.BatchSize
+		}
+
+		batch := e.spans[:size:size]
+		e.spans = e.spans[size:]
+
+		e.mu.Unlock()
+
+		if len(batch) == 0 {
+			return nil
+		}
+
+		err := e.send(batch)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Close stops background export and sends queued spans.
+func (e *OTLPExporter) Close() error {
+	e.once.Do(func() {
+		close(e.done)
+	})
+
+	<-e.stopped
+
+	return e.Flush()
+}
+
+func (e *OTLPExporter) send(spans []*SpanData) error {
+	body, err := json.Marshal(newOTLPRequest(spans))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, e.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	req.Header.Set("Content-Type", otlpContentType)
+
+	for key, value := range e.config.Headers {
+		req.Header.Set(key, value)
+	}
+
+	res, err := e.config.Client.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return errors.Errorf("otlp export: %s", res.Status)
+	}
+
+	return nil
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            *otlpStatus     `json:"status,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// newOTLPRequest groups spans by service.
+func newOTLPRequest(spans []*SpanData) *otlpRequest {
+	services := map[string][]otlpSpan{}
+
+	for _, span := range spans {
+		service := span.Service
+		if service == "" {
+			service = otlpUnknownService
+		}
+
+		services[service] = append(services[service], newOTLPSpan(span))
+	}
+
+	res := &otlpRequest{}
+
+	for service, spans := range services {
+		res.ResourceSpans = append(res.ResourceSpans, otlpResourceSpans{
+			Resource: otlpResource{
+				Attributes: []otlpAttribute{{
+					Key:   otlpServiceName,
+					Value: otlpValue{StringValue: service},
+				}},
+			},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: otlpScopeName},
+				Spans: spans,
+			}},
+		})
+	}
+
+	sort.Slice(res.ResourceSpans, func(i, j int) bool {
+		return res.ResourceSpans[i].Resource.Attributes[0].Value.StringValue <
+			res.ResourceSpans[j].Resource.Attributes[0].Value.StringValue
+	})
+
+	return res
+}
+
+func newOTLPSpan(span *SpanData) otlpSpan {
+	res := otlpSpan{
+		TraceID:           span.TraceID.String(),
+		SpanID:            span.SpanID.String(),
+		Name:              span.Name,
+		Kind:              otlpKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+	}
+
+	if span.ParentID.IsValid() {
+		res.ParentSpanID = span.ParentID.String()
+	}
+
+	keys := make([]string, 0, len(span.Attributes))
+	for key := range span.Attributes {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		res.Attributes = append(res.Attributes, otlpAttribute{
+			Key:   key,
+			Value: otlpValue{StringValue: span.Attributes[key]},
+		})
+	}
+
+	if span.Error != "" {
+		res.Status = &otlpStatus{
+			Code:    otlpStatusError,
+			Message: span.Error,
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,132 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	t.Parallel()
+
+	const value = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	res, err := ParseTraceParent(value)
+	require.NoError(t, err)
+	assert.True(t, res.IsValid())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", res.TraceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", res.SpanID.String())
+	assert.Equal(t, value, res.TraceParent())
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473z-00f067aa0ba902b7-01",
+	} {
+		_, err := ParseTraceParent(invalid)
+		assert.Error(t, err, invalid)
+	}
+
+	assert.Empty(t, SpanContext{}.TraceParent())
+}
+
+func TestTracer(t *testing.T) {
+	t.Parallel()
+
+	exporter := NewMemoryExporter()
+	tracer := NewTracer("test", exporter)
+
+	ctx, root := tracer.Start(context.Background(), "root")
+	_, child := tracer.Start(ctx, "child")
+
+	child.SetAttribute("room.id", 7)
+	child.SetError(errors.New("failed"))
+	child.End()
+	child.End()
+	root.End()
+
+	spans := exporter.Spans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "child", spans[0].Name)
+	assert.Equal(t, "test", spans[0].Service)
+	assert.Equal(t, root.Context().TraceID, spans[0].TraceID)
+	assert.Equal(t, root.Context().SpanID, spans[0].ParentID)
+	assert.Equal(t, map[string]string{"room.id": "7"}, spans[0].Attributes)
+	assert.Equal(t, "failed", spans[0].Error)
+	assert.False(t, spans[1].ParentID.IsValid())
+	assert.Len(t, exporter.Trace(root.Context().TraceID), 2)
+
+	// remote parent.
+	remote := ContextWithTraceParent(context.Background(), child.Context().TraceParent())
+	_, span := tracer.Start(remote, "remote")
+	span.End()
+
+	assert.Equal(t, child.Context().SpanID, exporter.Spans()[2].ParentID)
+	assert.Len(t, exporter.Trace(root.Context().TraceID), 3)
+}
+
+func TestNopTracer(t *testing.T) {
+	t.Parallel()
+
+	parent, err := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.NoError(t, err)
+
+	_, span := NopTracer().Start(ContextWithSpanContext(context.Background(), parent), "nop")
+	span.End()
+
+	assert.Equal(t, parent, span.Context())
+}
+
+func TestOTLPExporter(t *testing.T) {
+	t.Parallel()
+
+	requests := make(chan otlpRequest, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("Authorization"))
+		assert.Equal(t, otlpContentType, r.Header.Get("Content-Type"))
+
+		var req otlpRequest
+
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		requests <- req
+	}))
+	defer server.Close()
+
+	exporter, err := NewOTLPExporter(OTLPConfig{
+		Endpoint: server.URL,
+		Headers:  map[string]string{"Authorization": "secret"},
+		Interval: time.Hour,
+	})
+	require.NoError(t, err)
+
+	tracer := NewTracer("master", exporter)
+
+	_, span := tracer.Start(context.Background(), "master.CreateRoom")
+	span.SetError(errors.New("failed"))
+	span.End()
+
+	require.NoError(t, exporter.Close())
+
+	req := <-requests
+	require.Len(t, req.ResourceSpans, 1)
+	assert.Equal(t, "master", req.ResourceSpans[0].Resource.Attributes[0].Value.StringValue)
+
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	require.Len(t, spans, 1)
+	assert.Equal(t, "master.CreateRoom", spans[0].Name)
+	assert.Equal(t, span.Context().TraceID.String(), spans[0].TraceID)
+	assert.Empty(t, spans[0].ParentSpanID)
+	require.NotNil(t, spans[0].Status)
+	assert.Equal(t, otlpStatusError, spans[0].Status.Code)
+}
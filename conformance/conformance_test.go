@@ -0,0 +1,146 @@
+package conformance
+
+import (
+	"context"
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/engine/engtest"
+	"github.com/opoccomaxao-go/rooms/master"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/opoccomaxao-go/rooms/session"
+	"github.com/opoccomaxao-go/rooms/storage"
+	"github.com/stretchr/testify/require"
+)
+
+// Flags check external implementation, e.g. go test ./conformance -run TestExternal -master 127.0.0.1:22100 -token secret.
+var (
+	flagMaster  = flag.String("master", "", "address of master under test")
+	flagToken   = flag.String("token", "", "auth token for master under test")
+	flagSession = flag.String("session", "", "address to listen for session server under test")
+	flagCodec   = flag.String("codec", proto.CodecNameJSON, "payload codec")
+)
+
+const (
+	testHeartbeat       = time.Millisecond * 100
+	testHeartbeatMisses = 100 // testHeartbeatMisses keeps connection, suite doesn't send heartbeats.
+)
+
+func testContext(t *testing.T) context.Context {
+	ctx, cancelFn := context.WithCancel(context.Background())
+
+	t.Cleanup(cancelFn)
+
+	return ctx
+}
+
+func TestMaster(t *testing.T) {
+	t.Parallel()
+
+	const (
+		Address   = ":22150"
+		AuthToken = "token"
+	)
+
+	ctx := testContext(t)
+
+	storage := storage.NewRAM()
+	storage.Add(AuthToken)
+	storage.SetVersion(constants.Version)
+
+	mainServer, err := master.New(master.Config{
+		Storage:           storage,
+		SessionAddress:    Address,
+		HeartbeatInterval: testHeartbeat,
+		HeartbeatMisses:   testHeartbeatMisses,
+	})
+	require.NoError(t, err)
+
+	go func() {
+		_ = mainServer.Serve(ctx)
+	}()
+
+	time.Sleep(time.Second) // wait for main
+
+	for _, codec := range []proto.Codec{proto.JSON, proto.Binary} {
+		t.Run(codec.Name(), func(t *testing.T) {
+			RunMaster(t, MasterConfig{
+				Address: Address,
+				Token:   AuthToken,
+				Codec:   codec,
+				CreateRoom: func(ctx context.Context) error {
+					_, err := mainServer.CreateRoom(ctx, []uint64{1, 2})
+
+					return err
+				},
+			})
+		})
+	}
+}
+
+func TestSession(t *testing.T) {
+	t.Parallel()
+
+	// every run has own address, session server of previous run could reconnect.
+	for address, codec := range map[string]proto.Codec{
+		":22160": proto.JSON,
+		":22161": proto.Binary,
+	} {
+		address := address
+		codec := codec
+
+		t.Run(codec.Name(), func(t *testing.T) {
+			ctx := testContext(t)
+
+			RunSession(t, SessionConfig{
+				Address: address,
+				Codec:   codec,
+				Start: func() {
+					sessionServer, err := session.New(session.Config{
+						MasterAddress:     address,
+						Token:             []byte("token"),
+						EngineFactory:     engtest.New(),
+						HeartbeatInterval: testHeartbeat,
+						HeartbeatMisses:   testHeartbeatMisses,
+					})
+					require.NoError(t, err)
+
+					go func() {
+						_ = sessionServer.Serve(ctx)
+					}()
+				},
+			})
+		})
+	}
+}
+
+// TestExternal checks implementations set with flags.
+func TestExternal(t *testing.T) {
+	if *flagMaster == "" && *flagSession == "" {
+		t.Skip("-master and -session aren't set")
+	}
+
+	codec, err := proto.CodecByName(*flagCodec)
+	require.NoError(t, err)
+
+	if *flagMaster != "" {
+		t.Run("Master", func(t *testing.T) {
+			RunMaster(t, MasterConfig{
+				Address: *flagMaster,
+				Token:   *flagToken,
+				Codec:   codec,
+			})
+		})
+	}
+
+	if *flagSession != "" {
+		t.Run("Session", func(t *testing.T) {
+			RunSession(t, SessionConfig{
+				Address: *flagSession,
+				Codec:   codec,
+			})
+		})
+	}
+}
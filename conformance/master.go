@@ -0,0 +1,219 @@
+package conformance
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/opoccomaxao-go/ipc/event"
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// roomEndpoint is endpoint of rooms created by suite.
+const roomEndpoint = "127.0.0.1:9000"
+
+type MasterConfig struct {
+	Address string        // Address of master under test.
+	Token   string        // Token is valid auth token of session server.
+	Codec   proto.Codec   // optional. Codec requested on Auth. Default = proto.JSON
+	Timeout time.Duration // optional. Max wait for every reply. Default = constants.DefaultTimeout
+	// optional. CreateRoom requests room creation from master under test and waits for result.
+	// Room creation isn't checked if nil.
+	CreateRoom func(ctx context.Context) error
+}
+
+// RunMaster checks master implementation. Suite acts as session server with capacity of one room.
+func RunMaster(t *testing.T, cfg MasterConfig) {
+	t.Helper()
+
+	if cfg.Codec == nil {
+		cfg.Codec = proto.JSON
+	}
+
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = constants.DefaultTimeout
+	}
+
+	t.Run("AuthError", func(t *testing.T) { masterAuthError(t, cfg) })
+
+	session := dialMaster(t, cfg)
+	defer session.Close()
+
+	if !t.Run("Auth", func(t *testing.T) { masterAuth(t, session, cfg) }) {
+		return
+	}
+
+	t.Run("Invalid", func(t *testing.T) { masterInvalid(t, session) })
+	t.Run("Heartbeat", func(t *testing.T) { masterHeartbeat(t, session) })
+	t.Run("RoomCreate", func(t *testing.T) {
+		if cfg.CreateRoom == nil {
+			t.Skip("CreateRoom isn't set")
+		}
+
+		masterRoomCreate(t, session, cfg)
+	})
+}
+
+// dialMaster connects to master and checks AuthRequired without error.
+func dialMaster(t *testing.T, cfg MasterConfig) *peer {
+	t.Helper()
+
+	conn, err := net.DialTimeout("tcp", cfg.Address, cfg.Timeout)
+	require.NoError(t, err)
+
+	res := newPeer(conn, cfg.Timeout)
+
+	event := res.expectCommand(t, "AuthRequired", proto.CommandMasterAuthRequired)
+	require.Empty(t, event.Payload)
+
+	return res
+}
+
+func sendAuth(t *testing.T, session *peer, auth *proto.Auth) {
+	t.Helper()
+
+	payload, err := auth.Payload()
+	require.NoError(t, err)
+
+	session.send(t, proto.CommandSessionAuth, payload)
+}
+
+// masterAuthError checks AuthRequired with error on bad token and version.
+func masterAuthError(t *testing.T, cfg MasterConfig) {
+	session := dialMaster(t, cfg)
+	defer session.Close()
+
+	for _, test := range []struct {
+		auth *proto.Auth
+		code proto.ErrorCode
+	}{
+		{
+			auth: &proto.Auth{Version: constants.Version, Token: cfg.Token + "-invalid"},
+			code: proto.ErrorCodeBadToken,
+		},
+		{
+			auth: &proto.Auth{Version: "0", Token: cfg.Token},
+			code: proto.ErrorCodeVersionMismatch,
+		},
+	} {
+		sendAuth(t, session, test.auth)
+
+		event := session.expectCommand(t, "AuthRequired", proto.CommandMasterAuthRequired)
+
+		var authErr proto.Error
+
+		require.NoError(t, authErr.Read(event.Payload))
+		assert.Equal(t, test.code, authErr.Code)
+	}
+}
+
+// masterAuth authorizes with requested codec and reports capacity and empty inventory.
+func masterAuth(t *testing.T, session *peer, cfg MasterConfig) {
+	sendAuth(t, session, &proto.Auth{
+		Version: constants.Version,
+		Token:   cfg.Token,
+		Codec:   cfg.Codec.Name(),
+	})
+
+	event := session.expectCommand(t, "AuthSuccess", proto.CommandMasterAuthSuccess)
+
+	codec, err := proto.CodecByName(string(event.Payload))
+	require.NoError(t, err)
+
+	session.codec = codec
+
+	session.send(t, proto.CommandSessionStats, session.encode(t, &proto.Stats{Capacity: 1}))
+	session.send(t, proto.CommandSessionInventory, session.encode(t, &proto.Inventory{}))
+}
+
+// masterInvalid checks Nack on invalid reliable commands and Malformed on other invalid commands.
+func masterInvalid(t *testing.T, session *peer) {
+	header := session.sendReliable(t, proto.CommandSessionRoomCreated, []byte{0xff, 0})
+	event := session.expect(t, "Nack", isReply(proto.CommandMasterNack, header))
+
+	_, reason := readReliable(t, event)
+	assert.NotEmpty(t, reason)
+
+	session.send(t, proto.CommandSessionStats, []byte{0xff})
+	event = session.expectCommand(t, "Malformed", proto.CommandMasterMalformed)
+	readMalformed(t, event, proto.CommandSessionStats)
+}
+
+// masterHeartbeat checks periodic Heartbeat.
+func masterHeartbeat(t *testing.T, session *peer) {
+	session.send(t, proto.CommandSessionHeartbeat, nil)
+	session.expectCommand(t, "Heartbeat", proto.CommandMasterHeartbeat)
+}
+
+// masterRoomCreate checks creation and finish of room requested with CreateRoom.
+func masterRoomCreate(t *testing.T, session *peer, cfg MasterConfig) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancelFn()
+
+	res := make(chan error, 1)
+
+	go func() {
+		res <- cfg.CreateRoom(ctx)
+	}()
+
+	var rooms []*proto.Room
+
+	request := session.expect(t, "RoomCreate", func(event *event.Common) bool {
+		return event.Type == proto.CommandMasterRoomCreate || event.Type == proto.CommandMasterRoomCreateBatch
+	})
+
+	header, body := readReliable(t, request)
+
+	if request.Type == proto.CommandMasterRoomCreate {
+		var room proto.Room
+
+		session.decode(t, body, &room)
+
+		rooms = append(rooms, &room)
+	} else {
+		var batch proto.RoomBatch
+
+		session.decode(t, body, &batch)
+
+		rooms = batch.Rooms
+	}
+
+	session.send(t, proto.CommandSessionAck, header.Append(nil))
+
+	for _, room := range rooms {
+		room.Endpoint = roomEndpoint
+
+		payload := session.encode(t, room)
+		header := session.sendReliable(t, proto.CommandSessionRoomCreated, payload)
+		session.expect(t, "Ack", isReply(proto.CommandMasterAck, header))
+
+		session.send(t, proto.CommandSessionRoomCreated, header.Append(payload))
+		session.expect(t, "Ack of duplicate", isReply(proto.CommandMasterAck, header))
+	}
+
+	select {
+	case err := <-res:
+		require.NoError(t, err, "CreateRoom")
+	case <-ctx.Done():
+		require.FailNow(t, "CreateRoom timeout")
+	}
+
+	for _, room := range rooms {
+		room.Result = []byte(`{}`)
+
+		session.send(t, proto.CommandSessionRoomFinished, session.encode(t, room))
+		session.expect(t, "RoomAck", func(event *event.Common) bool {
+			if event.Type != proto.CommandMasterRoomAck {
+				return false
+			}
+
+			id, err := proto.ReadID(event.Payload)
+
+			return err == nil && id == room.ID
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package conformance
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/opoccomaxao-go/ipc/event"
+	"github.com/opoccomaxao-go/ipc/transport"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/opoccomaxao-go/rooms/reliable"
+	"github.com/stretchr/testify/require"
+)
+
+// peer is connection of suite with implementation under test.
+type peer struct {
+	transport transport.Transport
+	events    chan *event.Common
+	done      chan struct{}
+	codec     proto.Codec
+	timeout   time.Duration
+	epoch     uint64 // epoch is random for every connection, as after sender restart.
+	lastID    uint64
+}
+
+func newPeer(conn net.Conn, timeout time.Duration) *peer {
+	var epoch [8]byte
+
+	_, _ = rand.Read(epoch[:])
+
+	res := &peer{
+		transport: transport.NewSocket(conn),
+		events:    make(chan *event.Common),
+		done:      make(chan struct{}),
+		codec:     proto.JSON,
+		timeout:   timeout,
+		epoch:     binary.BigEndian.Uint64(epoch[:]),
+	}
+
+	go res.serve()
+
+	return res
+}
+
+func (p *peer) serve() {
+	defer close(p.events)
+
+	for {
+		event := &event.Common{}
+
+		if p.transport.Read(event) != nil {
+			return
+		}
+
+		select {
+		case p.events <- event:
+		case <-p.done:
+			return
+		}
+	}
+}
+
+func (p *peer) Close() error {
+	close(p.done)
+
+	return p.transport.Close()
+}
+
+func (p *peer) send(t *testing.T, command uint16, payload []byte) {
+	t.Helper()
+
+	require.NoError(t, p.transport.Write(&event.Common{
+		Type:    command,
+		Payload: payload,
+	}), "send %d", command)
+}
+
+// encode encodes msg with selected codec.
+func (p *peer) encode(t *testing.T, msg proto.Message) []byte {
+	t.Helper()
+
+	res, err := p.codec.Marshal(msg)
+	require.NoError(t, err)
+
+	return res
+}
+
+// decode decodes payload with selected codec and validates it.
+func (p *peer) decode(t *testing.T, payload []byte, msg proto.Message) {
+	t.Helper()
+
+	require.NoError(t, p.codec.Unmarshal(payload, msg))
+}
+
+// sendReliable sends command with new header.
+func (p *peer) sendReliable(t *testing.T, command uint16, payload []byte) reliable.Header {
+	t.Helper()
+
+	p.lastID++
+
+	header := reliable.Header{Epoch: p.epoch, ID: p.lastID}
+
+	p.send(t, command, header.Append(payload))
+
+	return header
+}
+
+// expect skips received commands until match returns true. Fails on timeout or disconnect.
+func (p *peer) expect(t *testing.T, name string, match func(*event.Common) bool) *event.Common {
+	t.Helper()
+
+	timer := time.NewTimer(p.timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case event, ok := <-p.events:
+			if !ok {
+				require.FailNow(t, "disconnected", "waiting for %s", name)
+			}
+
+			if match(event) {
+				return event
+			}
+		case <-timer.C:
+			require.FailNow(t, "timeout", "waiting for %s", name)
+		}
+	}
+}
+
+// expectCommand skips received commands until command.
+func (p *peer) expectCommand(t *testing.T, name string, command uint16) *event.Common {
+	t.Helper()
+
+	return p.expect(t, name, isCommand(command))
+}
+
+func isCommand(command uint16) func(*event.Common) bool {
+	return func(event *event.Common) bool {
+		return event.Type == command
+	}
+}
+
+// isReply matches Ack or Nack of reliable command with header.
+func isReply(command uint16, header reliable.Header) func(*event.Common) bool {
+	return func(event *event.Common) bool {
+		if event.Type != command {
+			return false
+		}
+
+		res, _, err := reliable.ReadHeader(event.Payload)
+
+		return err == nil && res == header
+	}
+}
+
+// readReliable splits payload of reliable command.
+func readReliable(t *testing.T, event *event.Common) (reliable.Header, []byte) {
+	t.Helper()
+
+	header, body, err := reliable.ReadHeader(event.Payload)
+	require.NoError(t, err, "command %d", event.Type)
+
+	return header, body
+}
+
+// readMalformed decodes Malformed and checks rejected command.
+func readMalformed(t *testing.T, event *event.Common, command uint16) {
+	t.Helper()
+
+	var report proto.Malformed
+
+	require.NoError(t, report.Read(event.Payload))
+	require.Equal(t, command, report.Command)
+	require.Equal(t, proto.ErrorCodeInvalid, report.Error.Code)
+}
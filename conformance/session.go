@@ -0,0 +1,185 @@
+package conformance
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/opoccomaxao-go/ipc/event"
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sessionRoomID is id of room created by suite on session server.
+const sessionRoomID = 1 << 32
+
+type SessionConfig struct {
+	Address string        // Address to listen. Session server under test connects to it as to master.
+	Codec   proto.Codec   // optional. Codec selected on AuthSuccess. Default = proto.JSON
+	Timeout time.Duration // optional. Max wait for every reply. Default = constants.DefaultTimeout
+	Start   func()        // optional. Start is called when Address is ready for session server.
+}
+
+// RunSession checks session server implementation. Suite acts as master for one connection of session server.
+func RunSession(t *testing.T, cfg SessionConfig) {
+	t.Helper()
+
+	if cfg.Codec == nil {
+		cfg.Codec = proto.JSON
+	}
+
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = constants.DefaultTimeout
+	}
+
+	listener, err := net.Listen("tcp", cfg.Address)
+	require.NoError(t, err)
+
+	defer listener.Close()
+
+	if cfg.Start != nil {
+		cfg.Start()
+	}
+
+	require.NoError(t, listener.(*net.TCPListener).SetDeadline(time.Now().Add(cfg.Timeout)))
+
+	conn, err := listener.Accept()
+	require.NoError(t, err, "session server isn't connected")
+
+	master := newPeer(conn, cfg.Timeout)
+	defer master.Close()
+
+	if !t.Run("Auth", func(t *testing.T) { sessionAuth(t, master, cfg.Codec) }) {
+		return
+	}
+
+	t.Run("Stats", func(t *testing.T) { sessionStats(t, master) })
+	t.Run("Inventory", func(t *testing.T) { sessionInventory(t, master) })
+	t.Run("RoomCreate", func(t *testing.T) { sessionRoomCreate(t, master) })
+	t.Run("Invalid", func(t *testing.T) { sessionInvalid(t, master) })
+	t.Run("RoomCancel", func(t *testing.T) { sessionRoomCancel(t, master) })
+	t.Run("Heartbeat", func(t *testing.T) { sessionHeartbeat(t, master) })
+}
+
+// sessionAuth checks Auth on AuthRequired and selects codec.
+func sessionAuth(t *testing.T, master *peer, codec proto.Codec) {
+	master.send(t, proto.CommandMasterAuthRequired, nil)
+
+	event := master.expectCommand(t, "Auth", proto.CommandSessionAuth)
+
+	var auth proto.Auth
+
+	require.NoError(t, auth.Read(event.Payload))
+	assert.Equal(t, constants.Version, auth.Version)
+
+	master.send(t, proto.CommandMasterAuthSuccess, []byte(codec.Name()))
+	master.codec = codec
+}
+
+// sessionStats checks Stats after AuthSuccess.
+func sessionStats(t *testing.T, master *peer) {
+	event := master.expectCommand(t, "Stats", proto.CommandSessionStats)
+
+	master.decode(t, event.Payload, &proto.Stats{})
+}
+
+// sessionInventory checks that all Inventory pages are received after AuthSuccess.
+func sessionInventory(t *testing.T, master *peer) {
+	for {
+		event := master.expectCommand(t, "Inventory", proto.CommandSessionInventory)
+
+		var inventory proto.Inventory
+
+		master.decode(t, event.Payload, &inventory)
+
+		if !inventory.More {
+			return
+		}
+	}
+}
+
+// sessionRoomCreate checks Ack and RoomCreated or RoomError on RoomCreate, duplicate is acknowledged only.
+func sessionRoomCreate(t *testing.T, master *peer) {
+	room := &proto.Room{
+		ID: sessionRoomID,
+		Clients: []*proto.Client{
+			{ID: 1, Team: 1},
+			{ID: 2, Team: 2},
+		},
+	}
+
+	payload := master.encode(t, room)
+	header := master.sendReliable(t, proto.CommandMasterRoomCreate, payload)
+
+	var acked, replied bool
+
+	for !acked || !replied {
+		master.expect(t, "Ack and RoomCreated", func(event *event.Common) bool {
+			switch {
+			case isReply(proto.CommandSessionAck, header)(event):
+				acked = true
+			case event.Type == proto.CommandSessionRoomCreated:
+				replyHeader, body := readReliable(t, event)
+
+				var res proto.Room
+
+				master.decode(t, body, &res)
+
+				replied = replied || res.ID == room.ID
+
+				master.send(t, proto.CommandMasterAck, replyHeader.Append(nil))
+			case event.Type == proto.CommandSessionRoomError:
+				var res proto.Room
+
+				master.decode(t, event.Payload, &res)
+
+				replied = replied || res.ID == room.ID
+
+				master.send(t, proto.CommandMasterRoomAck, proto.PayloadID(res.ID))
+			default:
+				return false
+			}
+
+			return true
+		})
+	}
+
+	master.send(t, proto.CommandMasterRoomCreate, header.Append(payload))
+
+	master.expect(t, "Ack of duplicate", func(event *event.Common) bool {
+		require.NotEqual(t, proto.CommandSessionRoomCreated, event.Type, "duplicate processed")
+
+		return isReply(proto.CommandSessionAck, header)(event)
+	})
+}
+
+// sessionInvalid checks Nack on invalid reliable commands and Malformed on other invalid commands.
+func sessionInvalid(t *testing.T, master *peer) {
+	header := master.sendReliable(t, proto.CommandMasterRoomCreate, []byte{0xff, 0})
+	event := master.expect(t, "Nack of undecodable room", isReply(proto.CommandSessionNack, header))
+
+	_, reason := readReliable(t, event)
+	assert.NotEmpty(t, reason)
+
+	header = master.sendReliable(t, proto.CommandMasterRoomCreateBatch, master.encode(t, &proto.RoomBatch{}))
+	master.expect(t, "Nack of empty batch", isReply(proto.CommandSessionNack, header))
+
+	master.send(t, proto.CommandMasterRoomAck, []byte{1, 2, 3})
+	event = master.expectCommand(t, "Malformed", proto.CommandSessionMalformed)
+	readMalformed(t, event, proto.CommandMasterRoomAck)
+}
+
+// sessionRoomCancel checks Ack on RoomCancel.
+func sessionRoomCancel(t *testing.T, master *peer) {
+	header := master.sendReliable(t, proto.CommandMasterRoomCancel, proto.PayloadID(sessionRoomID))
+
+	master.expect(t, "Ack", isReply(proto.CommandSessionAck, header))
+}
+
+// sessionHeartbeat checks periodic Heartbeat.
+func sessionHeartbeat(t *testing.T, master *peer) {
+	master.send(t, proto.CommandMasterHeartbeat, nil)
+	master.expectCommand(t, "Heartbeat", proto.CommandSessionHeartbeat)
+}
@@ -8,5 +8,12 @@ const (
 	DefaultTimeout          = time.Second * 10
 	DefaultTimeoutReconnect = time.Second * 10
 
-	Version = "1"
+	DefaultHeartbeatInterval = time.Second * 5
+	DefaultHeartbeatMisses   = 3
+
+	DefaultMaxAttempts     = 3
+	DefaultRetryBackoff    = time.Second
+	DefaultMaxRetryBackoff = time.Second * 30
+
+	Version = "3"
 )
@@ -1,8 +1,23 @@
 package constants
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
-	ErrNoParam = errors.New("no param")
-	ErrInvalid = errors.New("invalid")
+	ErrNoParam  = errors.New("no param")
+	ErrInvalid  = errors.New("invalid")
+	ErrNotFound = errors.New("not found")
+	ErrOverflow = errors.New("overflow")
+
+	ErrServerLost   = errors.New("server lost")
+	ErrTimeout      = errors.New("timeout")
+	ErrUnauthorized = errors.New("unauthorized")
+
+	ErrVersionMismatch = fmt.Errorf("version mismatch: %w", ErrInvalid)
+	ErrBadToken        = fmt.Errorf("bad token: %w", ErrInvalid)
+	ErrCapacity        = errors.New("capacity exhausted")
+	ErrEngineInit      = errors.New("engine init failed")
+	ErrMalformed       = fmt.Errorf("malformed payload: %w", ErrInvalid)
 )
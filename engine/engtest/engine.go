@@ -1,9 +1,32 @@
 package engtest
 
 import (
+	"time"
+
 	"github.com/opoccomaxao-go/rooms/engine"
+	"github.com/opoccomaxao-go/rooms/proto"
+)
+
+var (
+	_ engine.Engine      = (*Engine)(nil)
+	_ engine.Initializer = (*Engine)(nil)
 )
 
-var _ engine.Engine = (*Engine)(nil)
+type Engine struct {
+	Room *proto.Room
+
+	initDelay time.Duration
+	factory   *Factory
+}
+
+func (e *Engine) Init(room *proto.Room) error {
+	time.Sleep(e.initDelay)
+
+	e.Room = room
+
+	if e.factory != nil {
+		e.factory.onInit(room)
+	}
 
-type Engine struct{}
+	return nil
+}
@@ -1,17 +1,48 @@
 package engtest
 
 import (
+	"sync"
+	"time"
+
 	"github.com/opoccomaxao-go/rooms/engine"
+	"github.com/opoccomaxao-go/rooms/proto"
 )
 
 func New() engine.Factory {
 	return &Factory{}
 }
 
+// NewDelayed creates factory of engines which Init takes delay, e.g. to simulate slow room start.
+func NewDelayed(delay time.Duration) engine.Factory {
+	return &Factory{InitDelay: delay}
+}
+
 var _ engine.Factory = (*Factory)(nil)
 
-type Factory struct{}
+type Factory struct {
+	InitDelay time.Duration // optional. InitDelay is duration of Init.
+
+	rooms []*proto.Room
+	mu    sync.Mutex
+}
 
 func (f *Factory) New() engine.Engine {
-	return &Engine{}
+	return &Engine{
+		initDelay: f.InitDelay,
+		factory:   f,
+	}
+}
+
+// Rooms returns rooms passed to Init of all engines in order of Init.
+func (f *Factory) Rooms() []*proto.Room {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]*proto.Room(nil), f.rooms...)
+}
+
+func (f *Factory) onInit(room *proto.Room) {
+	f.mu.Lock()
+	f.rooms = append(f.rooms, room)
+	f.mu.Unlock()
 }
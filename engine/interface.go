@@ -1,7 +1,27 @@
 package engine
 
+import (
+	"time"
+
+	"github.com/opoccomaxao-go/rooms/proto"
+)
+
 type Factory interface {
 	New() Engine
 }
 
 type Engine interface{}
+
+// Initializer is optional Engine extension. Session server calls Init before room starts.
+type Initializer interface {
+	// Init prepares engine for room. Room clients contain teams and parties.
+	Init(room *proto.Room) error
+}
+
+// TickObserver records duration of one engine tick.
+type TickObserver func(duration time.Duration)
+
+// Instrumented is optional Engine extension. Session server calls Instrument before Init.
+type Instrumented interface {
+	Instrument(observeTick TickObserver)
+}
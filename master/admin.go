@@ -0,0 +1,274 @@
+package master
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/opoccomaxao-go/rooms/apm"
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/opoccomaxao-go/rooms/storage"
+	"github.com/pkg/errors"
+)
+
+// AdminRoom is room in admin API.
+type AdminRoom struct {
+	ID       uint64              `json:"id"`
+	ServerID uint64              `json:"server_id,omitempty"`
+	Endpoint string              `json:"endpoint,omitempty"`
+	Clients  []*proto.Client     `json:"clients,omitempty"`
+	Active   bool                `json:"active"`
+	History  *storage.RoomRecord `json:"history,omitempty"` // History is set if RoomStore is configured.
+}
+
+func newAdminRoom(room *proto.Room) *AdminRoom {
+	return &AdminRoom{
+		ID:       room.ID,
+		ServerID: room.ServerID,
+		Endpoint: room.Endpoint,
+		Clients:  room.Clients,
+		Active:   true,
+	}
+}
+
+// adminAPI serves HTTP/JSON admin API:
+//
+//	GET    /servers              connected session servers
+//	GET    /servers/{id}         session server
+//	POST   /servers/{id}/drain   exclude server from scheduling
+//	POST   /servers/{id}/undrain return server to scheduling
+//	GET    /rooms                active rooms
+//	POST   /rooms                create room from RoomSpec
+//	GET    /rooms/{id}           active room and its history
+//	DELETE /rooms/{id}           cancel room
+type adminAPI struct {
+	server *Server
+	token  []byte
+}
+
+// AdminHandler returns HTTP/JSON admin API handler. Requests require "Authorization: Bearer <AdminToken>",
+// all requests are rejected if AdminToken is empty.
+func (s *Server) AdminHandler() http.Handler {
+	return &adminAPI{
+		server: s,
+		token:  []byte(s.config.AdminToken),
+	}
+}
+
+func (a *adminAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	defer a.server.interval.Start("adminAPI.ServeHTTP").End()
+
+	if !a.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeAdminError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+
+		return
+	}
+
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	case path[0] == "servers" && len(path) == 1:
+		a.handle(w, r, http.MethodGet, a.servers)
+	case path[0] == "servers" && len(path) == 2:
+		a.handleID(w, r, http.MethodGet, path[1], a.server.serverByID)
+	case path[0] == "servers" && len(path) == 3 && path[2] == "drain":
+		a.handleID(w, r, http.MethodPost, path[1], a.server.drain)
+	case path[0] == "servers" && len(path) == 3 && path[2] == "undrain":
+		a.handleID(w, r, http.MethodPost, path[1], a.server.undrain)
+	case path[0] == "rooms" && len(path) == 1 && r.Method == http.MethodPost:
+		a.handle(w, r, http.MethodPost, a.createRoom)
+	case path[0] == "rooms" && len(path) == 1:
+		a.handle(w, r, http.MethodGet, a.rooms)
+	case path[0] == "rooms" && len(path) == 2 && r.Method == http.MethodDelete:
+		a.handleID(w, r, http.MethodDelete, path[1], a.server.cancelRoomByID)
+	case path[0] == "rooms" && len(path) == 2:
+		a.handleID(w, r, http.MethodGet, path[1], a.server.inspectRoom)
+	default:
+		writeAdminError(w, http.StatusNotFound, errors.WithStack(constants.ErrNotFound))
+	}
+}
+
+func (a *adminAPI) authorized(r *http.Request) bool {
+	if len(a.token) == 0 {
+		return false
+	}
+
+	token, ok := cutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), a.token) == 1
+}
+
+// cutPrefix returns s without prefix and reports whether prefix is found.
+func cutPrefix(s string, prefix string) (string, bool) {
+	if !strings.HasPrefix(s, prefix) {
+		return s, false
+	}
+
+	return s[len(prefix):], true
+}
+
+func (a *adminAPI) handle(
+	w http.ResponseWriter,
+	r *http.Request,
+	method string,
+	handler func(*http.Request) (interface{}, error),
+) {
+	if r.Method != method {
+		writeAdminError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+
+		return
+	}
+
+	res, err := handler(r)
+	if err != nil {
+		writeAdminError(w, adminStatus(err), err)
+
+		return
+	}
+
+	status := http.StatusOK
+	if method == http.MethodPost && res != nil {
+		status = http.StatusCreated
+	}
+
+	writeAdminJSON(w, status, res)
+}
+
+func (a *adminAPI) handleID(
+	w http.ResponseWriter,
+	r *http.Request,
+	method string,
+	rawID string,
+	handler func(uint64) (interface{}, error),
+) {
+	id, err := strconv.ParseUint(rawID, 10, 64)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, errors.Wrap(constants.ErrInvalid, "id"))
+
+		return
+	}
+
+	a.handle(w, r, method, func(*http.Request) (interface{}, error) {
+		return handler(id)
+	})
+}
+
+func (a *adminAPI) servers(*http.Request) (interface{}, error) {
+	return a.server.Servers(), nil
+}
+
+func (a *adminAPI) rooms(*http.Request) (interface{}, error) {
+	rooms := a.server.Rooms()
+	res := make([]*AdminRoom, len(rooms))
+
+	for i, room := range rooms {
+		res[i] = newAdminRoom(room)
+	}
+
+	return res, nil
+}
+
+func (a *adminAPI) createRoom(r *http.Request) (interface{}, error) {
+	var spec RoomSpec
+
+	err := json.NewDecoder(r.Body).Decode(&spec)
+	if err != nil {
+		return nil, errors.Wrap(constants.ErrInvalid, err.Error())
+	}
+
+	if len(spec.clientIDs()) == 0 {
+		return nil, errors.WithMessage(constants.ErrNoParam, "clients")
+	}
+
+	ctx := apm.ContextWithTraceParent(r.Context(), r.Header.Get("traceparent"))
+
+	room, err := a.server.CreateRoomFromSpec(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return newAdminRoom(room), nil
+}
+
+func (s *Server) serverByID(serverID uint64) (interface{}, error) {
+	return s.ServerByID(serverID)
+}
+
+func (s *Server) drain(serverID uint64) (interface{}, error) {
+	return nil, s.Drain(serverID)
+}
+
+func (s *Server) undrain(serverID uint64) (interface{}, error) {
+	return nil, s.Undrain(serverID)
+}
+
+func (s *Server) cancelRoomByID(roomID uint64) (interface{}, error) {
+	return nil, s.CancelRoom(roomID)
+}
+
+// inspectRoom returns active room with history or only history of finished room.
+func (s *Server) inspectRoom(roomID uint64) (interface{}, error) {
+	history, historyErr := s.RoomHistory(roomID)
+
+	room, err := s.Room(roomID)
+	if err != nil {
+		if historyErr != nil {
+			return nil, err
+		}
+
+		return &AdminRoom{
+			ID:       roomID,
+			ServerID: history.ServerID,
+			History:  history,
+		}, nil
+	}
+
+	res := newAdminRoom(room)
+	res.History = history
+
+	return res, nil
+}
+
+func adminStatus(err error) int {
+	var roomErr *proto.Error
+
+	switch {
+	case errors.Is(err, constants.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, constants.ErrInvalid), errors.Is(err, constants.ErrNoParam):
+		return http.StatusBadRequest
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, constants.ErrTimeout):
+		return http.StatusGatewayTimeout
+	case errors.As(err, &roomErr):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeAdminError(w http.ResponseWriter, status int, err error) {
+	writeAdminJSON(w, status, map[string]string{
+		"error": err.Error(),
+	})
+}
+
+func writeAdminJSON(w http.ResponseWriter, status int, value interface{}) {
+	if value == nil {
+		w.WriteHeader(http.StatusNoContent)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,150 @@
+package master
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func adminRequest(t *testing.T, handler http.Handler, method string, path string, token string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	return adminRequestAuth(t, handler, method, path, "Bearer "+token)
+}
+
+// adminRequestAuth sends request with raw Authorization header, header isn't set if empty.
+func adminRequestAuth(
+	t *testing.T,
+	handler http.Handler,
+	method string,
+	path string,
+	authorization string,
+) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+
+	return res
+}
+
+func TestAdminAPI(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+
+	server := newTestServer()
+	server.clients[1] = &connWrapper{
+		id:       1,
+		address:  "127.0.0.1:1000",
+		labels:   map[string]string{"region": "eu"},
+		stats:    proto.Stats{Capacity: 0},
+		ledger:   newLedger(time.Hour),
+		lastSeen: time.Now().UnixNano(),
+	}
+	server.addRoom(&proto.Room{ID: 10, ServerID: 2, Clients: []*proto.Client{{ID: 5}}})
+
+	events := server.Subscribe(ctx, SubscriberConfig{
+		Filter: EventFilter{Types: EventServerDrained},
+	})
+
+	handler := server.AdminHandler()
+
+	res := adminRequest(t, handler, http.MethodGet, "/servers", "wrong")
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+
+	res = adminRequest(t, handler, http.MethodGet, "/servers", "admin")
+	require.Equal(t, http.StatusOK, res.Code)
+
+	var servers []ServerInfo
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&servers))
+	require.Len(t, servers, 1)
+	assert.Equal(t, "127.0.0.1:1000", servers[0].Address)
+	assert.Equal(t, "eu", servers[0].Labels["region"])
+	assert.False(t, servers[0].Draining)
+
+	res = adminRequest(t, handler, http.MethodGet, "/servers/2", "admin")
+	assert.Equal(t, http.StatusNotFound, res.Code)
+
+	res = adminRequest(t, handler, http.MethodGet, "/servers/x", "admin")
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+
+	res = adminRequest(t, handler, http.MethodPost, "/servers/1/drain", "admin")
+	assert.Equal(t, http.StatusNoContent, res.Code)
+	assert.True(t, server.isDraining(1))
+
+	res = adminRequest(t, handler, http.MethodGet, "/rooms", "admin")
+	require.Equal(t, http.StatusOK, res.Code)
+
+	var rooms []*AdminRoom
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&rooms))
+	require.Len(t, rooms, 1)
+	assert.Equal(t, uint64(2), rooms[0].ServerID)
+
+	res = adminRequest(t, handler, http.MethodGet, "/rooms/10", "admin")
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	res = adminRequest(t, handler, http.MethodDelete, "/rooms/10", "admin")
+	assert.Equal(t, http.StatusNoContent, res.Code)
+
+	res = adminRequest(t, handler, http.MethodGet, "/rooms/10", "admin")
+	assert.Equal(t, http.StatusNotFound, res.Code)
+
+	res = adminRequest(t, handler, http.MethodPut, "/rooms", "admin")
+	assert.Equal(t, http.StatusMethodNotAllowed, res.Code)
+
+	res = adminRequest(t, handler, http.MethodPost, "/rooms", "admin")
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+
+	res = adminRequest(t, handler, http.MethodPost, "/servers/1/undrain", "admin")
+	assert.Equal(t, http.StatusNoContent, res.Code)
+	assert.False(t, server.isDraining(1))
+
+	select {
+	case event := <-events:
+		assert.Equal(t, uint64(1), event.ServerID)
+	default:
+		assert.Fail(t, "no drained event")
+	}
+}
+
+func TestAdminAPI_Unauthorized(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer()
+	handler := server.AdminHandler()
+
+	for name, authorization := range map[string]string{
+		"Missing":     "",
+		"WrongScheme": "Basic admin",
+		"NoScheme":    "admin",
+		"Wrong":       "Bearer wrong",
+	} {
+		res := adminRequestAuth(t, handler, http.MethodGet, "/servers", authorization)
+		assert.Equal(t, http.StatusUnauthorized, res.Code, name)
+		assert.Equal(t, "Bearer", res.Header().Get("WWW-Authenticate"), name)
+	}
+
+	// handler without token is closed.
+	server.config.AdminToken = ""
+	handler = server.AdminHandler()
+
+	res := adminRequestAuth(t, handler, http.MethodGet, "/servers", "Bearer ")
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+
+	res = adminRequestAuth(t, handler, http.MethodGet, "/servers", "")
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+}
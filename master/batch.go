@@ -0,0 +1,127 @@
+package master
+
+import (
+	"context"
+
+	"github.com/opoccomaxao-go/rooms/apm"
+	"github.com/opoccomaxao-go/rooms/proto"
+)
+
+type placedRoom struct {
+	index  int
+	server *connWrapper
+	waiter <-chan RoomCreateResult
+	span   apm.Span
+}
+
+// CreateRooms creates many rooms at once. Rooms are spread across session servers in one scheduling pass
+// and sent with batched RoomCreate. Results are in the same order as specs.
+// Retryable failures are retried on other servers up to MaxAttempts.
+func (s *Server) CreateRooms(ctx context.Context, specs []RoomSpec) []RoomCreateResult {
+	ctx, interval := apm.StartContext(ctx, s.interval, "CreateRooms")
+	defer interval.End()
+
+	places := make([]*placement, len(specs))
+	pending := make([]int, len(specs))
+
+	for index, spec := range specs {
+		places[index] = s.startPlacement(ctx, spec)
+		pending[index] = index
+	}
+
+	ctx, cancelFn := context.WithTimeout(ctx, s.config.CreateTimeout)
+	defer cancelFn()
+
+	for len(pending) > 0 {
+		statsUpdated := s.statsUpdated.Wait()
+
+		unplaced, retry := s.placeRooms(ctx, places, pending)
+
+		pending = unplaced
+
+		if len(pending) == 0 || retry {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			for _, index := range pending {
+				s.expirePlacement(places[index], ctx.Err())
+			}
+
+			pending = nil
+		case <-statsUpdated:
+		}
+	}
+
+	results := make([]RoomCreateResult, len(specs))
+
+	for index, place := range places {
+		s.observePlacement(place)
+
+		results[index] = place.result
+	}
+
+	return results
+}
+
+// placeRooms makes one creation attempt for every room.
+// Returns indexes of unfinished rooms and true if some of them failed and should be retried immediately.
+func (s *Server) placeRooms(ctx context.Context, places []*placement, indexes []int) ([]int, bool) {
+	ctx, interval := apm.StartContext(ctx, s.interval, "placeRooms")
+	defer interval.End()
+
+	attemptCtx, cancelFn := context.WithTimeout(ctx, s.config.AttemptTimeout)
+	defer cancelFn()
+
+	var (
+		placed   []placedRoom
+		unplaced []int
+		retry    bool
+		batches  = map[*connWrapper][]*placement{}
+	)
+
+	for _, index := range indexes {
+		place := places[index]
+
+		server := s.reserveFreeServer(ctx, place.room.ID, place.excluded)
+		if server == nil {
+			unplaced = append(unplaced, index)
+
+			continue
+		}
+
+		placed = append(placed, placedRoom{
+			index:  index,
+			server: server,
+			waiter: server.WaitRoomCreateResult(attemptCtx, place.room.ID),
+			span:   s.traceAttempt(place, server),
+		})
+
+		batches[server] = append(batches[server], place)
+	}
+
+	for server, batch := range batches {
+		rooms := make([]*proto.Room, len(batch))
+
+		for i, place := range batch {
+			rooms[i] = place.room
+		}
+
+		server.RoomCreateBatch(rooms)
+	}
+
+	for _, place := range placed {
+		res := <-place.waiter
+
+		place.span.SetError(res.Error)
+		place.span.End()
+
+		if !s.applyCreateResult(ctx, place.server, places[place.index], res) {
+			unplaced = append(unplaced, place.index)
+			retry = true
+		}
+	}
+
+	return unplaced, retry
+}
@@ -0,0 +1,47 @@
+package master
+
+import (
+	"github.com/opoccomaxao-go/ipc/event"
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/pkg/errors"
+)
+
+// CommandHandler handles custom command of authorized session server. Payload is owned by handler.
+type CommandHandler func(serverID uint64, payload []byte)
+
+// SendCommand sends custom command to connected session server. Command isn't resent after reconnect.
+func (s *Server) SendCommand(serverID uint64, command uint16, payload []byte) error {
+	defer s.interval.Start("SendCommand").End()
+
+	err := proto.ValidateCustomCommand(command, payload)
+	if err != nil {
+		return err
+	}
+
+	conn, ok := s.client(serverID)
+	if !ok || conn.isLost() {
+		return errors.Wrapf(constants.ErrNotFound, "server %d", serverID)
+	}
+
+	return conn.Command(command, payload)
+}
+
+// onCommand creates handler of custom command.
+func (c *connWrapper) onCommand(command uint16, handler CommandHandler) func([]byte) {
+	return func(payload []byte) {
+		defer c.interval.Start("onCommand").End()
+
+		handler(c.id, append([]byte(nil), payload...))
+	}
+}
+
+// Command sends custom command.
+func (c *connWrapper) Command(command uint16, payload []byte) error {
+	defer c.interval.Start("Command").End()
+
+	return errors.WithStack(c.conn.Send(&event.Common{
+		Type:    command,
+		Payload: payload,
+	}))
+}
@@ -3,6 +3,7 @@ package master
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/opoccomaxao-go/ipc/channel"
 	"github.com/opoccomaxao-go/ipc/event"
@@ -10,6 +11,8 @@ import (
 	"github.com/opoccomaxao-go/rooms/apm"
 	"github.com/opoccomaxao-go/rooms/constants"
 	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/opoccomaxao-go/rooms/reliable"
+	"github.com/opoccomaxao-go/rooms/storage"
 	"github.com/opoccomaxao-go/rooms/utils"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
@@ -29,8 +32,22 @@ type connWrapper struct {
 	// internal set only
 
 	id        uint64
+	address   string
+	labels    map[string]string
 	stats     proto.Stats
+	ledger    *ledger
+	lastSeen  int64 // lastSeen is unix time in nanoseconds, atomic.
+	lost      int32 // lost is 1 after disconnect, atomic.
 	listeners map[proto.ID][]chan RoomCreateResult
+	sender    *reliable.Sender
+	receiver  *reliable.Receiver
+	inventory []*proto.Room
+	synced    bool        // synced is true after inventory is reconciled, rooms of server are known since.
+	drained   bool        // drained is true after EventServerDrained until capacity is reported.
+	codec     proto.Codec // codec encodes payloads, selected on Auth.
+
+	failures     int       // failures is count of consecutive failed room creations.
+	backoffUntil time.Time // backoffUntil is end of scheduling exclusion.
 
 	mu sync.RWMutex
 }
@@ -38,8 +55,34 @@ type connWrapper struct {
 func (c *connWrapper) init() {
 	c.logger = c.parent.config.Logger.With().
 		Logger()
-	c.interval = apm.NewZerologInterval(&c.logger, "master.connWrapper.")
+	c.interval = c.parent.config.Intervals("master.connWrapper.")
 	c.listeners = map[uint64][]chan RoomCreateResult{}
+	c.codec = proto.JSON
+	c.ledger = newLedger(c.parent.config.ReservationTimeout)
+	c.sender = reliable.NewSender(c.conn.Send, c.onNack)
+	c.receiver = reliable.NewReceiver(c.conn.Send, proto.CommandMasterAck, proto.CommandMasterNack)
+}
+
+func (c *connWrapper) getCodec() proto.Codec {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.codec
+}
+
+// payload encodes msg with codec of connection.
+func (c *connWrapper) payload(msg proto.Message) ([]byte, error) {
+	res, err := c.getCodec().Marshal(msg)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return res, nil
+}
+
+// read decodes payload with codec of connection.
+func (c *connWrapper) read(payload []byte, msg proto.Message) error {
+	return errors.WithStack(c.getCodec().Unmarshal(payload, msg))
 }
 
 func (c *connWrapper) onAuth(payload []byte) {
@@ -65,27 +108,77 @@ func (c *connWrapper) onAuth(payload []byte) {
 		return
 	}
 
-	c.parent.register(id, c)
-	c.parent.unregister(c.id, c)
+	codec, err := proto.CodecByName(auth.Codec)
+	if err != nil {
+		c.logger.Err(err).Stack().Send()
+
+		codec = proto.JSON
+	}
+
+	c.mu.Lock()
+	c.address = auth.Address
+	c.labels = auth.Labels
+	c.codec = codec
+	c.mu.Unlock()
+
+	// session server switches codec on AuthSuccess, so no command is sent before it.
+	c.AuthSuccess(codec)
+
+	prevID := c.id
 	c.id = id
-	c.AuthSuccess()
+	c.parent.register(id, c)
+
+	if prevID != id {
+		c.parent.unregister(prevID, c)
+	}
+
+	c.parent.publish(Event{
+		Type:     EventServerAuthenticated,
+		ServerID: id,
+	})
+
+	err = errors.WithStack(c.sender.Resend())
+	if err != nil {
+		c.logger.Err(err).Stack().Send()
+	}
 }
 
-func (c *connWrapper) onRoomCreated(payload []byte) {
+// authorized wraps handler of command, commands before Auth are ignored.
+func (c *connWrapper) authorized(command uint16, handler func([]byte)) func([]byte) {
+	return func(payload []byte) {
+		if c.id == 0 {
+			c.logger.Warn().
+				Uint16("type", command).
+				Msg("unauthorized")
+
+			return
+		}
+
+		handler(payload)
+	}
+}
+
+// onRoomCreated handles reliable command, malformed one is rejected with Nack.
+func (c *connWrapper) onRoomCreated(payload []byte) error {
 	defer c.interval.Start("onRoomCreated").End()
 
 	var room proto.Room
 
-	err := room.Read(payload)
+	err := c.read(payload, &room)
 	if err != nil {
 		c.logger.Err(err).Stack().Send()
 
-		return
+		return err
 	}
 
+	c.ledger.confirm(room.ID)
+	c.parent.traceReply("master.onRoomCreated", c.id, &room)
+
 	c.notifyRoomCreate(room.ID, RoomCreateResult{
 		Room: &room,
 	})
+
+	return nil
 }
 
 func (c *connWrapper) onRoomError(payload []byte) {
@@ -93,16 +186,34 @@ func (c *connWrapper) onRoomError(payload []byte) {
 
 	var room proto.Room
 
-	err := room.Read(payload)
+	err := c.read(payload, &room)
 	if err != nil {
 		c.logger.Err(err).Stack().Send()
+		c.Malformed(proto.CommandSessionRoomError, err)
 
 		return
 	}
 
+	c.ledger.release(room.ID)
+	c.parent.traceReply("master.onRoomError", c.id, &room)
+
 	c.notifyRoomCreate(room.ID, RoomCreateResult{
-		Error: errors.New(room.Error),
+		Error: errors.WithStack(roomError(&room)),
 	})
+
+	c.RoomAck(room.ID)
+}
+
+// roomError returns error of room from RoomError. Missing error is unknown.
+func roomError(room *proto.Room) *proto.Error {
+	if room.Error == nil {
+		return &proto.Error{
+			Code:    proto.ErrorCodeUnknown,
+			Message: "unknown error",
+		}
+	}
+
+	return room.Error
 }
 
 func (c *connWrapper) onRoomFinished(payload []byte) {
@@ -110,45 +221,252 @@ func (c *connWrapper) onRoomFinished(payload []byte) {
 
 	var room proto.Room
 
-	err := room.Read(payload)
+	err := c.read(payload, &room)
+	if err != nil {
+		c.logger.Err(err).Stack().Send()
+		c.Malformed(proto.CommandSessionRoomFinished, err)
+
+		return
+	}
+
+	room.ServerID = c.id
+
+	if !c.parent.acceptResult(c, room.ID) {
+		// result resent after reconnect is already processed.
+		c.logger.Debug().
+			Uint64("room", room.ID).
+			Msg("duplicate finish")
+		c.RoomAck(room.ID)
+
+		return
+	}
+
+	// result is not acknowledged, session server sends it again.
+	err = c.parent.appendOutbox(&room)
 	if err != nil {
 		c.logger.Err(err).Stack().Send()
 
 		return
 	}
 
+	c.parent.finishRoom(room.ID)
+	c.parent.saveRoomEvent(&storage.RoomEvent{
+		Status:   storage.RoomStatusFinished,
+		RoomID:   room.ID,
+		ServerID: c.id,
+		Error:    room.Error.Error(),
+		Result:   room.Result,
+	})
+
 	c.parent.notifyFinishedRoom(&room)
+	c.parent.publishRoom(EventRoomFinished, c.id, &room, nil)
+	c.parent.metrics.roomsFinished.Inc()
+	c.parent.checkDrained(c)
+
+	c.RoomAck(room.ID)
+}
+
+// onMalformed logs command rejected by session server.
+func (c *connWrapper) onMalformed(payload []byte) {
+	defer c.interval.Start("onMalformed").End()
+
+	var report proto.Malformed
+
+	err := report.Read(payload)
+	if err != nil {
+		c.logger.Err(err).Stack().Send()
+
+		return
+	}
+
+	c.logger.Error().
+		Uint16("type", report.Command).
+		Err(report.Error).
+		Msg("malformed")
+}
+
+// onNack handles rejected reliable commands.
+func (c *connWrapper) onNack(source *event.Common, reason string) {
+	defer c.interval.Start("onNack").End()
+
+	c.logger.Error().
+		Uint16("type", source.Type).
+		Str("reason", reason).
+		Msg("rejected")
+
+	var rooms []*proto.Room
+
+	switch source.Type {
+	case proto.CommandMasterRoomCreate:
+		var room proto.Room
+
+		err := c.read(source.Payload, &room)
+		if err != nil {
+			c.logger.Err(err).Stack().Send()
+
+			return
+		}
+
+		rooms = append(rooms, &room)
+	case proto.CommandMasterRoomCreateBatch:
+		var batch proto.RoomBatch
+
+		err := c.read(source.Payload, &batch)
+		if err != nil {
+			c.logger.Err(err).Stack().Send()
+
+			return
+		}
+
+		rooms = batch.Rooms
+	default:
+		return
+	}
+
+	for _, room := range rooms {
+		c.ledger.release(room.ID)
+
+		c.notifyRoomCreate(room.ID, RoomCreateResult{
+			Error: errors.New(reason),
+		})
+	}
+}
+
+func (c *connWrapper) onInventory(payload []byte) {
+	defer c.interval.Start("onInventory").End()
+
+	var inventory proto.Inventory
+
+	err := c.read(payload, &inventory)
+	if err != nil {
+		c.logger.Err(err).Stack().Send()
+		c.Malformed(proto.CommandSessionInventory, err)
+
+		return
+	}
+
+	c.inventory = append(c.inventory, inventory.Rooms...)
+
+	if inventory.More {
+		return
+	}
+
+	rooms := c.inventory
+	c.inventory = nil
+
+	c.parent.reconcile(c, rooms)
+	c.synced = true
 }
 
 func (c *connWrapper) onStats(payload []byte) {
 	defer c.interval.Start("onStats").End()
 
-	err := errors.WithStack(c.stats.Read(payload))
+	var stats proto.Stats
+
+	err := c.read(payload, &stats)
 	if err != nil {
 		c.logger.Err(err).Stack().Send()
+		c.Malformed(proto.CommandSessionStats, err)
+
+		return
 	}
 
+	c.mu.Lock()
+	changed := c.stats != stats
+	c.stats = stats
+	c.mu.Unlock()
+
+	c.ledger.releaseConfirmed()
+
 	c.parent.onStats()
+
+	if changed {
+		c.parent.publish(Event{
+			Type:     EventServerStats,
+			ServerID: c.id,
+			Stats:    &stats,
+		})
+	}
+
+	c.parent.checkDrained(c)
+}
+
+// freeCapacity returns reported capacity without reserved slots. Lost servers have no capacity.
+func (c *connWrapper) freeCapacity() uint64 {
+	if c.isLost() {
+		return 0
+	}
+
+	capacity := c.capacity()
+	reserved := c.ledger.count()
+
+	if capacity <= reserved {
+		return 0
+	}
+
+	return capacity - reserved
+}
+
+func (c *connWrapper) capacity() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.stats.Capacity
+}
+
+// reserve takes one free slot for room. Returns false if there is no free slot.
+func (c *connWrapper) reserve(roomID uint64) bool {
+	return c.ledger.reserve(roomID, c.capacity())
+}
+
+// release returns slot taken by reserve.
+func (c *connWrapper) release(roomID uint64) {
+	c.ledger.release(roomID)
 }
 
 func (c *connWrapper) Serve() {
 	defer c.interval.Start("Serve").End()
 
 	c.clearWaiters()
+	c.touch()
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+
+	go c.serveHeartbeat(ctx)
 
 	handler := processor.New()
 	handler.Register(proto.CommandSessionAuth, c.onAuth)
-	handler.Register(proto.CommandSessionRoomCreated, c.onRoomCreated)
-	handler.Register(proto.CommandSessionRoomError, c.onRoomError)
-	handler.Register(proto.CommandSessionRoomFinished, c.onRoomFinished)
-	handler.Register(proto.CommandSessionStats, c.onStats)
+	handler.Register(proto.CommandSessionHeartbeat, c.onHeartbeat)
+
+	for command, fn := range map[uint16]func([]byte){
+		proto.CommandSessionRoomCreated:  c.receiver.WrapErr(c.onRoomCreated),
+		proto.CommandSessionRoomError:    c.onRoomError,
+		proto.CommandSessionRoomFinished: c.onRoomFinished,
+		proto.CommandSessionStats:        c.onStats,
+		proto.CommandSessionInventory:    c.onInventory,
+		proto.CommandSessionAck:          c.sender.OnAck,
+		proto.CommandSessionNack:         c.sender.OnNack,
+		proto.CommandSessionMalformed:    c.onMalformed,
+	} {
+		handler.Register(command, c.authorized(command, fn))
+	}
+
+	for command, fn := range c.parent.config.Commands {
+		handler.Register(command, c.authorized(command, c.onCommand(command, fn)))
+	}
 
 	c.AuthRequired(nil)
 
-	err := errors.WithStack(c.conn.Serve(handler))
+	err := errors.WithStack(c.conn.Serve(channel.HandlerFunc[*event.Common](func(event *event.Common) {
+		c.touch()
+		handler.Handle(event)
+	})))
 	if err != nil {
 		c.logger.Err(err).Stack().Send()
 	}
+
+	c.parent.onDisconnect(c)
 }
 
 // FlushInstance take all unsent data from other equal server.
@@ -159,6 +477,10 @@ func (c *connWrapper) FlushInstance(other *connWrapper) error {
 		return errors.Wrapf(constants.ErrInvalid, "illegal instance id: %d, required %d", other.id, c.id)
 	}
 
+	c.sender.Take(other.sender)
+	c.receiver.Take(other.receiver)
+	c.ledger.take(other.ledger)
+
 	err := other.Close()
 	if err != nil {
 		return errors.WithStack(err)
@@ -200,20 +522,19 @@ func (c *connWrapper) clearWaiters() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	go c.closeAll(c.listeners)
+	go c.failAll(c.listeners)
 
 	c.listeners = map[uint64][]chan RoomCreateResult{}
 }
 
-func (c *connWrapper) closeAll(allWaiters map[uint64][]chan RoomCreateResult) {
-	defer c.interval.Start("closeAll").End()
-
-	if allWaiters == nil {
-		return
-	}
+// failAll notifies waiters about lost connection. Waiters are closed by their owners.
+func (c *connWrapper) failAll(allWaiters map[uint64][]chan RoomCreateResult) {
+	defer c.interval.Start("failAll").End()
 
 	for _, waiters := range allWaiters {
-		utils.WithChannels(waiters).Close()
+		utils.WithChannels(waiters).TryNotify(RoomCreateResult{
+			Error: errors.WithStack(constants.ErrServerLost),
+		})
 	}
 }
 
@@ -239,38 +560,110 @@ func (c *connWrapper) AuthRequired(err error) {
 	}
 
 	if err != nil {
-		event.Payload = []byte(err.Error())
+		payload, err := proto.NewError(err).Payload()
+		if err != nil {
+			c.logger.Err(err).Stack().Send()
+		}
+
+		event.Payload = payload
 	}
 
 	c.conn.Send(&event)
 }
 
-func (c *connWrapper) AuthSuccess() {
+// AuthSuccess confirms auth with name of selected codec.
+func (c *connWrapper) AuthSuccess(codec proto.Codec) {
 	defer c.interval.Start("AuthSuccess").End()
 
 	c.conn.Send(&event.Common{
-		Type: proto.CommandMasterAuthSuccess,
+		Type:    proto.CommandMasterAuthSuccess,
+		Payload: []byte(codec.Name()),
 	})
 }
 
+// RoomCreate sends room. Encoding error is passed to waiters of room. Room is encoded again on resend with codec of connection.
 func (c *connWrapper) RoomCreate(room *proto.Room) {
 	defer c.interval.Start("RoomCreate").End()
 
+	encode, err := proto.NewEncoder(room, c.getCodec)
+	if err != nil {
+		c.notifyRoomCreate(room.ID, RoomCreateResult{
+			Error: errors.WithStack(err),
+		})
+
+		return
+	}
+
+	_ = c.sender.SendEncoded(proto.CommandMasterRoomCreate, encode)
+}
+
+// RoomCreateBatch sends rooms with as few commands as payload size allows.
+func (c *connWrapper) RoomCreateBatch(rooms []*proto.Room) {
+	defer c.interval.Start("RoomCreateBatch").End()
+
+	batches, err := proto.SplitRoomBatch(rooms, proto.MaxPayloadSize-reliable.HeaderSize)
+	if err != nil {
+		c.failRooms(rooms, err)
+
+		return
+	}
+
+	for _, batch := range batches {
+		encode, err := proto.NewEncoder(batch, c.getCodec)
+		if err != nil {
+			c.failRooms(batch.Rooms, errors.WithStack(err))
+
+			continue
+		}
+
+		_ = c.sender.SendEncoded(proto.CommandMasterRoomCreateBatch, encode)
+	}
+}
+
+// failRooms passes encoding error to waiters of rooms.
+func (c *connWrapper) failRooms(rooms []*proto.Room, err error) {
+	for _, room := range rooms {
+		c.notifyRoomCreate(room.ID, RoomCreateResult{
+			Error: err,
+		})
+	}
+}
+
+// Malformed reports rejected command with invalid payload to session server.
+func (c *connWrapper) Malformed(command uint16, err error) {
+	defer c.interval.Start("Malformed").End()
+
+	payload, err := proto.NewMalformed(command, err).Payload()
+	if err != nil {
+		c.logger.Err(err).Stack().Send()
+
+		return
+	}
+
 	c.conn.Send(&event.Common{
-		Type:    proto.CommandMasterRoomCreate,
-		Payload: room.Payload(),
+		Type:    proto.CommandMasterMalformed,
+		Payload: payload,
 	})
 }
 
 func (c *connWrapper) RoomCancel(roomID proto.ID) {
 	defer c.interval.Start("RoomCancel").End()
 
-	c.conn.Send(&event.Common{
+	c.sender.Send(&event.Common{
 		Type:    proto.CommandMasterRoomCancel,
 		Payload: proto.PayloadID(roomID),
 	})
 }
 
+func (c *connWrapper) RoomAck(roomID proto.ID) {
+	defer c.interval.Start("RoomAck").End()
+
+	c.conn.Send(&event.Common{
+		Type:    proto.CommandMasterRoomAck,
+		Payload: proto.PayloadID(roomID),
+	})
+}
+
 func (c *connWrapper) Close() error {
 	defer c.interval.Start("Close").End()
 
@@ -0,0 +1,207 @@
+package master
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/opoccomaxao-go/ipc/channel"
+	"github.com/opoccomaxao-go/ipc/event"
+	"github.com/opoccomaxao-go/ipc/transport"
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/opoccomaxao-go/rooms/reliable"
+	"github.com/opoccomaxao-go/rooms/storage"
+	"github.com/opoccomaxao-go/rooms/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConnWrapper_RoomFinishedResend checks that result resent after reconnect is processed once.
+func TestConnWrapper_RoomFinishedResend(t *testing.T) {
+	t.Parallel()
+
+	ram := storage.NewRAM()
+	ram.SetVersion(constants.Version)
+	ram.Add(fuzzToken)
+
+	outbox := storage.NewRAMOutbox(DefaultOutboxCapacity)
+
+	server, err := New(Config{
+		Storage:     ram,
+		Outbox:      outbox,
+		LostTimeout: time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	finished := fuzzEncode(t, proto.JSON, fuzzRoom())
+
+	for _, inventory := range []bool{false, true} {
+		peer, done := fuzzConnect(server)
+
+		send := func(command uint16, payload []byte) {
+			require.NoError(t, peer.Write(&event.Common{
+				Type:    command,
+				Payload: payload,
+			}))
+		}
+
+		send(proto.CommandSessionAuth, fuzzSeeds(t, proto.CommandSessionAuth, proto.JSON)[0])
+		send(proto.CommandSessionRoomFinished, finished)
+
+		if inventory {
+			send(proto.CommandSessionInventory, fuzzEncode(t, proto.JSON, &proto.Inventory{}))
+			send(proto.CommandSessionRoomFinished, finished)
+		}
+
+		// heartbeat is read after previous command is handled.
+		send(proto.CommandSessionHeartbeat, nil)
+
+		require.NoError(t, peer.Close())
+		<-done
+	}
+
+	entries, err := outbox.Read(1, outboxReadBatch)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+// TestConnWrapper_Unauthorized checks that commands before Auth are ignored.
+func TestConnWrapper_Unauthorized(t *testing.T) {
+	t.Parallel()
+
+	ram := storage.NewRAM()
+	ram.SetVersion(constants.Version)
+	ram.Add(fuzzToken)
+
+	outbox := storage.NewRAMOutbox(DefaultOutboxCapacity)
+
+	server, err := New(Config{
+		Storage:     ram,
+		Outbox:      outbox,
+		LostTimeout: time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	peer, done := fuzzConnect(server)
+
+	send := func(command uint16, payload []byte) {
+		require.NoError(t, peer.Write(&event.Common{
+			Type:    command,
+			Payload: payload,
+		}))
+	}
+
+	send(proto.CommandSessionRoomFinished, fuzzEncode(t, proto.JSON, fuzzRoom()))
+	send(proto.CommandSessionStats, fuzzEncode(t, proto.JSON, &proto.Stats{Capacity: 10}))
+	send(proto.CommandSessionAuth, fuzzSeeds(t, proto.CommandSessionAuth, proto.JSON)[0])
+
+	// heartbeat is read after previous command is handled.
+	send(proto.CommandSessionHeartbeat, nil)
+
+	servers := server.Servers()
+	require.Len(t, servers, 1)
+	assert.Zero(t, servers[0].Stats.Capacity, "stats before Auth are ignored")
+
+	require.NoError(t, peer.Close())
+	<-done
+
+	entries, err := outbox.Read(1, outboxReadBatch)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "result before Auth is ignored")
+}
+
+// TestConnWrapper_RoomFinishedOverflow checks that result isn't acknowledged until it is stored in outbox.
+func TestConnWrapper_RoomFinishedOverflow(t *testing.T) {
+	t.Parallel()
+
+	outbox := storage.NewRAMOutbox(1)
+
+	_, err := outbox.Offset("consumer")
+	require.NoError(t, err)
+
+	_, err = outbox.Append(&proto.Room{ID: 100})
+	require.NoError(t, err)
+
+	server := newTestServer()
+	server.config.Outbox = outbox
+	server.outboxUpdated = utils.NewSignal()
+
+	var sent []*event.Common
+
+	local, remote := net.Pipe()
+	defer remote.Close()
+
+	acks := make(chan uint16, 1)
+
+	go func() {
+		peer := transport.NewSocket(remote)
+
+		var buffer event.Common
+
+		for peer.Read(&buffer) == nil {
+			acks <- buffer.Type
+		}
+	}()
+
+	conn := newTestConn(server, 1, &sent)
+	conn.conn = &channel.Channel{
+		Transport: transport.NewSocket(local),
+		TryCount:  1,
+	}
+	conn.codec = proto.JSON
+	conn.synced = true
+
+	server.addRoom(&proto.Room{ID: 1, ServerID: 1})
+
+	finished := fuzzEncode(t, proto.JSON, &proto.Room{ID: 1})
+
+	conn.onRoomFinished(finished)
+
+	select {
+	case <-acks:
+		require.Fail(t, "result is acknowledged")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	_, err = server.Room(1)
+	require.NoError(t, err, "room isn't finished")
+
+	require.NoError(t, outbox.Ack("consumer", 1))
+
+	conn.onRoomFinished(finished)
+	assert.Equal(t, proto.CommandMasterRoomAck, <-acks)
+
+	_, err = server.Room(1)
+	require.Error(t, err)
+
+	entries, err := outbox.Read(2, 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, uint64(1), entries[0].Room.ID)
+}
+
+// TestConnWrapper_ResendCodec checks that pending command is encoded again with codec selected on reconnect.
+func TestConnWrapper_ResendCodec(t *testing.T) {
+	t.Parallel()
+
+	var sent []*event.Common
+
+	conn := newTestConn(newTestServer(), 1, &sent)
+	conn.codec = proto.JSON
+
+	room := fuzzRoom()
+	conn.RoomCreate(room)
+
+	conn.codec = proto.Binary
+	require.NoError(t, conn.sender.Resend())
+	require.Len(t, sent, 2)
+
+	_, body, err := reliable.ReadHeader(sent[1].Payload)
+	require.NoError(t, err)
+
+	var res proto.Room
+
+	require.NoError(t, proto.Binary.Unmarshal(body, &res))
+	assert.Equal(t, room, &res)
+}
@@ -0,0 +1,251 @@
+package master
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"golang.org/x/exp/slices"
+)
+
+const DefaultEventCapacity = 100
+
+// EventType is type of cluster event. Types are bit flags and could be combined in EventFilter.
+type EventType uint32
+
+const (
+	EventServerConnected     EventType = 1 << iota // EventServerConnected occurs on new session server connection.
+	EventServerAuthenticated                       // EventServerAuthenticated occurs on successful Auth.
+	EventServerStats                               // EventServerStats occurs on changed Stats.
+	EventServerDisconnected                        // EventServerDisconnected occurs on lost connection.
+	EventServerDrained                             // EventServerDrained occurs when draining server or server without capacity has no rooms.
+	EventRoomCreated                               // EventRoomCreated occurs on created or adopted room.
+	EventRoomError                                 // EventRoomError occurs on every failed creation attempt.
+	EventRoomFinished                              // EventRoomFinished occurs on RoomFinished.
+	EventRoomCancelled                             // EventRoomCancelled occurs on CancelRoom.
+	EventRoomLost                                  // EventRoomLost occurs on room missing after reconnect or server loss.
+
+	EventServer = EventServerConnected | EventServerAuthenticated | EventServerStats |
+		EventServerDisconnected | EventServerDrained
+	EventRoom = EventRoomCreated | EventRoomError | EventRoomFinished | EventRoomCancelled | EventRoomLost
+	EventAll  = EventServer | EventRoom
+)
+
+var eventTypeNames = map[EventType]string{
+	EventServerConnected:     "server_connected",
+	EventServerAuthenticated: "server_authenticated",
+	EventServerStats:         "server_stats",
+	EventServerDisconnected:  "server_disconnected",
+	EventServerDrained:       "server_drained",
+	EventRoomCreated:         "room_created",
+	EventRoomError:           "room_error",
+	EventRoomFinished:        "room_finished",
+	EventRoomCancelled:       "room_cancelled",
+	EventRoomLost:            "room_lost",
+}
+
+func (t EventType) String() string {
+	if name, ok := eventTypeNames[t]; ok {
+		return name
+	}
+
+	return "unknown"
+}
+
+// Event is change of cluster state.
+type Event struct {
+	Type     EventType
+	At       time.Time
+	ServerID uint64       // ServerID is zero for not authenticated server and room errors without server.
+	Room     *proto.Room  // Room is set for room events.
+	Stats    *proto.Stats // Stats is set for EventServerStats.
+	Error    error        // Error is set for EventRoomError.
+}
+
+// EventFilter selects events for subscriber. Zero fields match all events.
+type EventFilter struct {
+	Types    EventType // optional. Combination of event types. Default = EventAll
+	ServerID uint64    // optional. Only events of server.
+	RoomID   uint64    // optional. Only events of room.
+}
+
+func (f EventFilter) match(event *Event) bool {
+	if f.Types != 0 && f.Types&event.Type == 0 {
+		return false
+	}
+
+	if f.ServerID != 0 && f.ServerID != event.ServerID {
+		return false
+	}
+
+	if f.RoomID != 0 && (event.Room == nil || f.RoomID != event.Room.ID) {
+		return false
+	}
+
+	return true
+}
+
+type SubscriberConfig struct {
+	Filter   EventFilter
+	Capacity int            // optional. Default = DefaultEventCapacity
+	Overflow OverflowPolicy // Overflow is slow subscriber policy, OverflowBlock blocks publisher. Default = OverflowDrop
+	Timeout  time.Duration  // optional. Timeout for OverflowBlock. Default = constants.DefaultTimeout
+}
+
+type subscriber struct {
+	ctx     context.Context
+	config  SubscriberConfig
+	channel chan Event
+	closed  bool
+	mu      sync.Mutex
+}
+
+// send delivers event according to overflow policy. Returns false if subscriber should be disconnected.
+func (s *subscriber) send(event Event) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return true
+	}
+
+	return deliver(s.ctx, s.channel, event, s.config.Overflow, s.config.Timeout)
+}
+
+func (s *subscriber) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		close(s.channel)
+	}
+}
+
+// Subscribe creates channel-receiver of cluster events. To close channel cancel context ctx.
+// Channel is closed by OverflowDisconnect policy too.
+func (s *Server) Subscribe(ctx context.Context, cfg SubscriberConfig) <-chan Event {
+	defer s.interval.Start("Subscribe").End()
+
+	if cfg.Capacity <= 0 {
+		cfg.Capacity = DefaultEventCapacity
+	}
+
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = constants.DefaultTimeout
+	}
+
+	sub := &subscriber{
+		ctx:     ctx,
+		config:  cfg,
+		channel: make(chan Event, cfg.Capacity),
+	}
+
+	s.subscribersMu.Lock()
+	s.subscribers = append(s.subscribers, sub)
+	s.subscribersMu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		s.unsubscribe(sub)
+	}()
+
+	return sub.channel
+}
+
+func (s *Server) unsubscribe(sub *subscriber) {
+	defer s.interval.Start("unsubscribe").End()
+
+	s.subscribersMu.Lock()
+
+	index := slices.Index(s.subscribers, sub)
+	if index != -1 {
+		s.subscribers = slices.Delete(s.subscribers, index, index+1)
+	}
+
+	s.subscribersMu.Unlock()
+
+	sub.close()
+}
+
+// publish sends event to all matching subscribers.
+func (s *Server) publish(event Event) {
+	defer s.interval.Start("publish").End()
+
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+
+	s.subscribersMu.RLock()
+	subscribers := slices.Clone(s.subscribers)
+	s.subscribersMu.RUnlock()
+
+	for _, sub := range subscribers {
+		if !sub.config.Filter.match(&event) {
+			continue
+		}
+
+		if !sub.send(event) {
+			s.unsubscribe(sub)
+		}
+	}
+}
+
+func (s *Server) publishRoom(eventType EventType, serverID uint64, room *proto.Room, err error) {
+	s.publish(Event{
+		Type:     eventType,
+		ServerID: serverID,
+		Room:     room,
+		Error:    err,
+	})
+}
+
+// checkDrained publishes EventServerDrained once draining server or server without capacity has no rooms.
+func (s *Server) checkDrained(conn *connWrapper) {
+	defer s.interval.Start("checkDrained").End()
+
+	if conn.id == 0 {
+		return
+	}
+
+	draining := s.isDraining(conn.id)
+
+	conn.mu.Lock()
+
+	accepting := conn.stats.Capacity > 0 && !draining
+	if accepting {
+		conn.drained = false
+	}
+
+	if conn.drained || accepting || s.serverRooms(conn.id) > 0 {
+		conn.mu.Unlock()
+
+		return
+	}
+
+	conn.drained = true
+	conn.mu.Unlock()
+
+	s.publish(Event{
+		Type:     EventServerDrained,
+		ServerID: conn.id,
+	})
+}
+
+// serverRooms returns count of active rooms of server.
+func (s *Server) serverRooms(serverID uint64) int {
+	s.roomsMu.RLock()
+	defer s.roomsMu.RUnlock()
+
+	res := 0
+
+	for _, room := range s.rooms {
+		if room.ServerID == serverID {
+			res++
+		}
+	}
+
+	return res
+}
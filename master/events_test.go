@@ -0,0 +1,101 @@
+package master
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/opoccomaxao-go/rooms/apm"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/opoccomaxao-go/rooms/utils"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer() *Server {
+	logger := zerolog.Nop()
+
+	res := &Server{
+		config: Config{
+			Logger:     &logger,
+			AdminToken: "admin",
+			Metrics:    apm.NewRegistry(),
+		},
+		interval:     apm.NewZerologInterval(&logger, "test."),
+		clients:      map[uint64]*connWrapper{},
+		drains:       map[uint64]struct{}{},
+		statsUpdated: utils.NewSignal(),
+		rooms:        map[uint64]*proto.Room{},
+		cancels:      map[uint64]uint64{},
+		finished:     map[uint64]struct{}{},
+	}
+
+	res.initMetrics()
+
+	return res
+}
+
+func TestEventFilter(t *testing.T) {
+	t.Parallel()
+
+	event := Event{
+		Type:     EventRoomCreated,
+		ServerID: 2,
+		Room:     &proto.Room{ID: 3},
+	}
+
+	assert.True(t, EventFilter{}.match(&event))
+	assert.True(t, EventFilter{Types: EventRoom, ServerID: 2, RoomID: 3}.match(&event))
+	assert.False(t, EventFilter{Types: EventServer}.match(&event))
+	assert.False(t, EventFilter{ServerID: 1}.match(&event))
+	assert.False(t, EventFilter{RoomID: 1}.match(&event))
+	assert.False(t, EventFilter{RoomID: 1}.match(&Event{Type: EventServerStats}))
+}
+
+func TestServer_Subscribe(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+
+	server := newTestServer()
+
+	rooms := server.Subscribe(ctx, SubscriberConfig{
+		Filter:   EventFilter{Types: EventRoom},
+		Capacity: 1,
+		Overflow: OverflowDrop,
+	})
+	strict := server.Subscribe(ctx, SubscriberConfig{
+		Capacity: 1,
+		Overflow: OverflowDisconnect,
+	})
+
+	server.publish(Event{Type: EventServerConnected})
+	server.publishRoom(EventRoomCreated, 1, &proto.Room{ID: 1}, nil)
+	server.publishRoom(EventRoomFinished, 1, &proto.Room{ID: 1}, nil)
+
+	event := <-rooms
+	assert.Equal(t, EventRoomCreated, event.Type)
+	assert.False(t, event.At.IsZero())
+
+	select {
+	case event := <-rooms:
+		require.Fail(t, "dropped event received", event.Type.String())
+	default:
+	}
+
+	event = <-strict
+	assert.Equal(t, EventServerConnected, event.Type)
+
+	_, ok := <-strict
+	assert.False(t, ok, "disconnected")
+
+	cancelFn()
+
+	assert.Eventually(t, func() bool {
+		_, ok := <-rooms
+
+		return !ok
+	}, time.Second, time.Millisecond)
+}
@@ -0,0 +1,221 @@
+package master
+
+import (
+	"encoding/json"
+	"net"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/opoccomaxao-go/ipc/channel"
+	"github.com/opoccomaxao-go/ipc/event"
+	"github.com/opoccomaxao-go/ipc/transport"
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/opoccomaxao-go/rooms/reliable"
+	"github.com/opoccomaxao-go/rooms/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fuzzToken = "token"
+
+// fuzzCommands are all commands registered in connWrapper.Serve.
+// Payloads of reliable commands get valid header, so fuzzer reaches handlers.
+var fuzzCommands = []struct {
+	command  uint16
+	reliable bool
+}{
+	{command: proto.CommandSessionAuth},
+	{command: proto.CommandSessionRoomCreated, reliable: true},
+	{command: proto.CommandSessionRoomError},
+	{command: proto.CommandSessionRoomFinished},
+	{command: proto.CommandSessionStats},
+	{command: proto.CommandSessionAck},
+	{command: proto.CommandSessionNack},
+	{command: proto.CommandSessionInventory},
+	{command: proto.CommandSessionHeartbeat},
+	{command: proto.CommandSessionMalformed},
+}
+
+func fuzzRoom() *proto.Room {
+	return &proto.Room{
+		ID: 1,
+		Clients: []*proto.Client{
+			{ID: 1, Token: []byte("token"), Team: 1},
+			{ID: 2, Team: 2},
+		},
+		Result: json.RawMessage(`{"winner":1}`),
+	}
+}
+
+// fuzzSeeds returns valid payloads of command.
+func fuzzSeeds(t testing.TB, command uint16, codec proto.Codec) [][]byte {
+	t.Helper()
+
+	encode := func(msg proto.Message) []byte {
+		return fuzzEncode(t, codec, msg)
+	}
+
+	failed := fuzzRoom()
+	failed.Error = proto.NewError(constants.ErrCapacity)
+
+	switch command {
+	case proto.CommandSessionAuth:
+		return [][]byte{fuzzEncode(t, proto.JSON, &proto.Auth{
+			Version: constants.Version,
+			Token:   fuzzToken,
+			Codec:   codec.Name(),
+		})}
+	case proto.CommandSessionRoomCreated, proto.CommandSessionRoomFinished:
+		return [][]byte{encode(fuzzRoom())}
+	case proto.CommandSessionRoomError:
+		return [][]byte{encode(failed)}
+	case proto.CommandSessionStats:
+		return [][]byte{encode(&proto.Stats{Capacity: 2})}
+	case proto.CommandSessionAck:
+		return [][]byte{reliable.Header{ID: 1}.Append(nil)}
+	case proto.CommandSessionNack:
+		return [][]byte{reliable.Header{ID: 1}.Append([]byte("rejected"))}
+	case proto.CommandSessionInventory:
+		return [][]byte{
+			encode(&proto.Inventory{Rooms: []*proto.Room{fuzzRoom()}}),
+			encode(&proto.Inventory{Rooms: []*proto.Room{fuzzRoom()}, More: true}),
+		}
+	case proto.CommandSessionMalformed:
+		return [][]byte{fuzzEncode(t, proto.JSON, proto.NewMalformed(proto.CommandMasterRoomCreate, constants.ErrMalformed))}
+	default:
+		return nil
+	}
+}
+
+func fuzzEncode(t testing.TB, codec proto.Codec, msg proto.Message) []byte {
+	t.Helper()
+
+	res, err := codec.Marshal(msg)
+	require.NoError(t, err)
+
+	return res
+}
+
+// FuzzConnWrapper sends arbitrary payload of every command to master over in-memory connection.
+// Master must not panic, must keep rooms consistent and must release all goroutines after disconnect.
+func FuzzConnWrapper(f *testing.F) {
+	for index, command := range fuzzCommands {
+		for _, codec := range []proto.Codec{proto.JSON, proto.Binary} {
+			binary := codec == proto.Binary
+
+			f.Add(uint8(index), binary, []byte{})
+
+			for _, payload := range fuzzSeeds(f, command.command, codec) {
+				f.Add(uint8(index), binary, payload)
+			}
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, index uint8, binary bool, payload []byte) {
+		command := fuzzCommands[int(index)%len(fuzzCommands)]
+
+		codec := proto.JSON
+		if binary {
+			codec = proto.Binary
+		}
+
+		if command.reliable {
+			payload = reliable.Header{Epoch: 1, ID: 1}.Append(payload)
+		}
+
+		goroutines := runtime.NumGoroutine()
+
+		ram := storage.NewRAM()
+		ram.SetVersion(constants.Version)
+		ram.Add(fuzzToken)
+
+		server, err := New(Config{
+			Storage:     ram,
+			LostTimeout: time.Millisecond,
+		})
+		require.NoError(t, err)
+
+		peer, done := fuzzConnect(server)
+
+		send := func(command uint16, payload []byte) {
+			require.NoError(t, peer.Write(&event.Common{
+				Type:    command,
+				Payload: payload,
+			}))
+		}
+
+		send(proto.CommandSessionAuth, fuzzSeeds(t, proto.CommandSessionAuth, codec)[0])
+		send(command.command, payload)
+		// heartbeat is read after previous command is handled.
+		send(proto.CommandSessionHeartbeat, nil)
+
+		_, ok := server.client(1)
+		assert.True(t, ok, "registered")
+
+		for _, room := range server.Rooms() {
+			assert.Equal(t, uint64(1), room.ServerID, "room %d", room.ID)
+			assert.NoError(t, room.Validate(), "room %d", room.ID)
+		}
+
+		require.NoError(t, peer.Close())
+		<-done
+
+		assert.Eventually(t, func() bool {
+			server.mu.RLock()
+			defer server.mu.RUnlock()
+
+			server.roomsMu.RLock()
+			defer server.roomsMu.RUnlock()
+
+			return len(server.clients) == 0 && len(server.rooms) == 0 && len(server.cancels) == 0
+		}, time.Second, time.Millisecond, "expired server state")
+
+		requireGoroutines(t, goroutines)
+	})
+}
+
+// fuzzConnect serves in-memory session server connection. Returns transport of session server,
+// incoming commands are discarded. Done is closed after disconnect is handled.
+func fuzzConnect(server *Server) (transport.Transport, <-chan struct{}) {
+	local, remote := net.Pipe()
+	peer := transport.NewSocket(remote)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		server.handle(&channel.Channel{
+			Transport: transport.NewSocket(local),
+			TryCount:  1,
+		})
+	}()
+
+	go func() {
+		var buffer event.Common
+
+		for peer.Read(&buffer) == nil {
+		}
+	}()
+
+	return peer, done
+}
+
+// requireGoroutines waits until count of goroutines is not greater than expected.
+func requireGoroutines(t *testing.T, expected int) {
+	t.Helper()
+
+	deadline := time.Now().Add(constants.DefaultTimeout)
+
+	for runtime.NumGoroutine() > expected {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<20)
+
+			require.Failf(t, "goroutine leak", "%d > %d\n%s",
+				runtime.NumGoroutine(), expected, buf[:runtime.Stack(buf, true)])
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,102 @@
+package master
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/opoccomaxao-go/ipc/event"
+	"github.com/opoccomaxao-go/rooms/proto"
+)
+
+// touch marks session server as alive.
+func (c *connWrapper) touch() {
+	atomic.StoreInt64(&c.lastSeen, time.Now().UnixNano())
+}
+
+// LastSeen returns time of last received command.
+func (c *connWrapper) LastSeen() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&c.lastSeen))
+}
+
+// isLost returns true for disconnected or unresponsive session server.
+func (c *connWrapper) isLost() bool {
+	return atomic.LoadInt32(&c.lost) != 0
+}
+
+func (c *connWrapper) onHeartbeat(_ []byte) {}
+
+func (c *connWrapper) Heartbeat() {
+	defer c.interval.Start("Heartbeat").End()
+
+	c.conn.Send(&event.Common{
+		Type: proto.CommandMasterHeartbeat,
+	})
+}
+
+// serveHeartbeat sends heartbeats and closes connection after HeartbeatMisses intervals of silence.
+func (c *connWrapper) serveHeartbeat(ctx context.Context) {
+	defer c.interval.Start("serveHeartbeat").End()
+
+	interval := c.parent.config.HeartbeatInterval
+	timeout := interval * time.Duration(c.parent.config.HeartbeatMisses)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if now.Sub(c.LastSeen()) > timeout {
+				c.logger.Warn().
+					Uint64("server", c.id).
+					Time("last_seen", c.LastSeen()).
+					Msg("heartbeat timeout")
+
+				_ = c.Close()
+
+				return
+			}
+
+			c.Heartbeat()
+		}
+	}
+}
+
+// onDisconnect excludes session server from scheduling and fails all its waiters.
+// Rooms of server are lost if server doesn't reconnect during LostTimeout.
+func (s *Server) onDisconnect(conn *connWrapper) {
+	defer s.interval.Start("onDisconnect").End()
+
+	atomic.StoreInt32(&conn.lost, 1)
+
+	conn.clearWaiters()
+
+	s.publish(Event{
+		Type:     EventServerDisconnected,
+		ServerID: conn.id,
+	})
+
+	if conn.id == 0 {
+		return
+	}
+
+	time.AfterFunc(s.config.LostTimeout, func() {
+		s.expireServer(conn)
+	})
+}
+
+// expireServer removes session server which didn't reconnect and marks its rooms as lost.
+func (s *Server) expireServer(conn *connWrapper) {
+	defer s.interval.Start("expireServer").End()
+
+	current, ok := s.client(conn.id)
+	if !ok || current != conn {
+		return
+	}
+
+	s.unregister(conn.id, conn)
+	s.reconcile(conn, nil)
+}
@@ -0,0 +1,80 @@
+package master
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/opoccomaxao-go/ipc/event"
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/opoccomaxao-go/rooms/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestServer_HeartbeatTimeout checks eviction of silent session server, its waiters and rooms.
+func TestServer_HeartbeatTimeout(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+
+	ram := storage.NewRAM()
+	ram.SetVersion(constants.Version)
+	ram.Add(fuzzToken)
+
+	server, err := New(Config{
+		Storage:           ram,
+		HeartbeatInterval: time.Millisecond * 10,
+		HeartbeatMisses:   2,
+		LostTimeout:       time.Millisecond * 50,
+	})
+	require.NoError(t, err)
+
+	events := server.Subscribe(ctx, SubscriberConfig{
+		Filter:   EventFilter{Types: EventServerDisconnected | EventRoomLost},
+		Capacity: 10,
+	})
+
+	peer, done := fuzzConnect(server)
+	defer peer.Close()
+
+	require.NoError(t, peer.Write(&event.Common{
+		Type:    proto.CommandSessionAuth,
+		Payload: fuzzSeeds(t, proto.CommandSessionAuth, proto.JSON)[0],
+	}))
+	// heartbeat is read after Auth is handled.
+	require.NoError(t, peer.Write(&event.Common{Type: proto.CommandSessionHeartbeat}))
+
+	conn, ok := server.client(1)
+	require.True(t, ok)
+
+	waiter := conn.WaitRoomCreateResult(ctx, 10)
+	server.addRoom(&proto.Room{ID: 10, ServerID: 1})
+
+	// session server doesn't send heartbeats.
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		require.FailNow(t, "connection isn't closed")
+	}
+
+	assert.True(t, conn.isLost())
+
+	result := <-waiter
+	require.ErrorIs(t, result.Error, constants.ErrServerLost)
+
+	event := <-events
+	assert.Equal(t, EventServerDisconnected, event.Type)
+
+	event = <-events
+	assert.Equal(t, EventRoomLost, event.Type)
+	assert.Equal(t, uint64(10), event.Room.ID)
+
+	_, ok = server.client(1)
+	assert.False(t, ok, "expired")
+
+	_, err = server.Room(10)
+	require.ErrorIs(t, err, constants.ErrNotFound)
+}
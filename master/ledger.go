@@ -0,0 +1,116 @@
+package master
+
+import (
+	"sync"
+	"time"
+)
+
+type lease struct {
+	expires   time.Time
+	confirmed bool // confirmed lease is released by next Stats.
+}
+
+// ledger keeps reserved capacity slots of session server.
+// Slot is consumed on room placement and released on room error, timeout, next Stats after creation or lease expiry.
+type ledger struct {
+	ttl    time.Duration
+	leases map[uint64]*lease
+	mu     sync.Mutex
+}
+
+func newLedger(ttl time.Duration) *ledger {
+	return &ledger{
+		ttl:    ttl,
+		leases: map[uint64]*lease{},
+	}
+}
+
+func (l *ledger) purge(now time.Time) {
+	for id, lease := range l.leases {
+		if !now.Before(lease.expires) {
+			delete(l.leases, id)
+		}
+	}
+}
+
+// count returns count of active leases.
+func (l *ledger) count() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.purge(time.Now())
+
+	return uint64(len(l.leases))
+}
+
+// reserve takes slot for room if there are less than capacity active leases.
+func (l *ledger) reserve(roomID uint64, capacity uint64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	l.purge(now)
+
+	if _, ok := l.leases[roomID]; ok {
+		return true
+	}
+
+	if uint64(len(l.leases)) >= capacity {
+		return false
+	}
+
+	l.leases[roomID] = &lease{
+		expires: now.Add(l.ttl),
+	}
+
+	return true
+}
+
+// confirm marks room as created.
+func (l *ledger) confirm(roomID uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if lease, ok := l.leases[roomID]; ok {
+		lease.confirmed = true
+	}
+}
+
+func (l *ledger) release(roomID uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.leases, roomID)
+}
+
+// releaseConfirmed releases all created rooms, Stats already count them.
+func (l *ledger) releaseConfirmed() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for id, lease := range l.leases {
+		if lease.confirmed {
+			delete(l.leases, id)
+		}
+	}
+}
+
+// take moves all leases from other ledger.
+func (l *ledger) take(other *ledger) {
+	if l == other {
+		return
+	}
+
+	other.mu.Lock()
+	defer other.mu.Unlock()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for id, lease := range other.leases {
+		l.leases[id] = lease
+	}
+
+	other.leases = map[uint64]*lease{}
+}
@@ -0,0 +1,42 @@
+package master
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLedger(t *testing.T) {
+	t.Parallel()
+
+	ledger := newLedger(time.Hour)
+
+	assert.True(t, ledger.reserve(1, 2))
+	assert.True(t, ledger.reserve(2, 2))
+	assert.True(t, ledger.reserve(2, 2), "same room")
+	assert.False(t, ledger.reserve(3, 2))
+	assert.Equal(t, uint64(2), ledger.count())
+
+	ledger.release(1)
+	assert.True(t, ledger.reserve(3, 2))
+
+	ledger.confirm(2)
+	ledger.releaseConfirmed()
+	assert.Equal(t, uint64(1), ledger.count())
+}
+
+func TestLedger_Expiry(t *testing.T) {
+	t.Parallel()
+
+	ledger := newLedger(10 * time.Millisecond)
+
+	assert.True(t, ledger.reserve(1, 1))
+	assert.False(t, ledger.reserve(2, 1))
+
+	assert.Eventually(t, func() bool {
+		return ledger.count() == 0
+	}, time.Second, time.Millisecond)
+
+	assert.True(t, ledger.reserve(2, 1))
+}
@@ -0,0 +1,22 @@
+package matchmaking
+
+import "github.com/opoccomaxao-go/rooms/proto"
+
+type EventType int
+
+const (
+	// EventMatchFound occurs when room for match is created.
+	EventMatchFound EventType = iota + 1
+	// EventMatchFailed occurs when room for match isn't created. Tickets which didn't leave are returned to queue.
+	EventMatchFailed
+	// EventTimeout occurs when ticket waits longer than Config.Timeout. Ticket is removed from queue.
+	EventTimeout
+)
+
+type Event struct {
+	Type   EventType
+	Match  *Match      // Match is set for EventMatchFound and EventMatchFailed.
+	Room   *proto.Room // Room is set for EventMatchFound.
+	Ticket *Ticket     // Ticket is set for EventTimeout.
+	Error  error       // Error is set for EventMatchFailed.
+}
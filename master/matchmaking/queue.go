@@ -0,0 +1,313 @@
+package matchmaking
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/opoccomaxao-go/rooms/apm"
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/master"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/opoccomaxao-go/rooms/utils"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	DefaultInterval         = time.Second
+	DefaultTimeout          = time.Minute * 5
+	DefaultListenerCapacity = 100
+)
+
+// Creator creates rooms for formed matches.
+type Creator interface {
+	CreateRoomFromSpec(ctx context.Context, spec master.RoomSpec) (*proto.Room, error)
+}
+
+// implements interface.
+var _ Creator = (*master.Server)(nil)
+
+type Config struct {
+	Creator  Creator       // Creator creates rooms for matches.
+	Rule     Rule          // Rule forms matches, rule with Validate method is validated by New.
+	Interval time.Duration // optional. Interval between matching passes. Default = DefaultInterval
+	Timeout  time.Duration // optional. Max waiting time of ticket. Default = DefaultTimeout
+
+	Logger    *zerolog.Logger
+	Intervals apm.IntervalFactory // optional. Default = apm.ZerologIntervals(Logger)
+}
+
+type Queue struct {
+	config   Config
+	interval apm.DebuggableInterval
+
+	lastID    uint64
+	tickets   map[uint64]*Ticket
+	placing   map[uint64]*Ticket // placing contains matched tickets while room is created.
+	players   map[uint64]uint64  // players contains ticket id of every waiting or placing player.
+	listeners []chan Event
+
+	mu sync.Mutex
+}
+
+func New(cfg Config) (*Queue, error) {
+	if cfg.Creator == nil {
+		return nil, errors.WithMessage(constants.ErrNoParam, "Creator")
+	}
+
+	if cfg.Rule == nil {
+		return nil, errors.WithMessage(constants.ErrNoParam, "Rule")
+	}
+
+	if rule, ok := cfg.Rule.(validator); ok {
+		err := rule.Validate()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+
+	if cfg.Logger == nil {
+		logger := zerolog.Nop()
+		cfg.Logger = &logger
+	}
+
+	if cfg.Intervals == nil {
+		cfg.Intervals = apm.ZerologIntervals(cfg.Logger)
+	}
+
+	return &Queue{
+		config:   cfg,
+		interval: cfg.Intervals("matchmaking.Queue."),
+		tickets:  map[uint64]*Ticket{},
+		placing:  map[uint64]*Ticket{},
+		players:  map[uint64]uint64{},
+	}, nil
+}
+
+// Join adds ticket into queue and returns its id. Every player could be queued once.
+func (q *Queue) Join(ticket Ticket) (uint64, error) {
+	defer q.interval.Start("Join").End()
+
+	if len(ticket.Players) == 0 {
+		return 0, errors.Wrap(constants.ErrInvalid, "empty ticket")
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, player := range ticket.Players {
+		if _, ok := q.players[player]; ok {
+			return 0, errors.Wrapf(constants.ErrInvalid, "player %d already queued", player)
+		}
+	}
+
+	q.lastID++
+
+	ticket.ID = q.lastID
+	ticket.Players = append([]uint64(nil), ticket.Players...)
+	ticket.Created = time.Now()
+
+	q.add(&ticket)
+
+	return ticket.ID, nil
+}
+
+// Leave removes ticket from queue. Placing ticket isn't returned to queue if room isn't created.
+func (q *Queue) Leave(ticketID uint64) error {
+	defer q.interval.Start("Leave").End()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	ticket, ok := q.tickets[ticketID]
+	if !ok {
+		ticket, ok = q.placing[ticketID]
+	}
+
+	if !ok {
+		return errors.Wrapf(constants.ErrNotFound, "ticket %d", ticketID)
+	}
+
+	q.remove(ticket)
+
+	return nil
+}
+
+// Len returns count of waiting tickets.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.tickets)
+}
+
+func (q *Queue) add(ticket *Ticket) {
+	q.tickets[ticket.ID] = ticket
+
+	for _, player := range ticket.Players {
+		q.players[player] = ticket.ID
+	}
+}
+
+func (q *Queue) remove(ticket *Ticket) {
+	delete(q.tickets, ticket.ID)
+	delete(q.placing, ticket.ID)
+
+	for _, player := range ticket.Players {
+		delete(q.players, player)
+	}
+}
+
+// Serve runs matching passes until ctx is done.
+func (q *Queue) Serve(ctx context.Context) error {
+	defer q.interval.Start("Serve").End()
+
+	ticker := time.NewTicker(q.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			q.pass(ctx, now)
+		}
+	}
+}
+
+// pass removes expired tickets and creates rooms for formed matches.
+func (q *Queue) pass(ctx context.Context, now time.Time) {
+	defer q.interval.Start("pass").End()
+
+	q.mu.Lock()
+
+	var expired []*Ticket
+
+	for _, ticket := range q.tickets {
+		if now.Sub(ticket.Created) >= q.config.Timeout {
+			expired = append(expired, ticket)
+			q.remove(ticket)
+		}
+	}
+
+	tickets := maps.Values(q.tickets)
+	slices.SortFunc(tickets, func(a, b *Ticket) bool {
+		return a.ID < b.ID
+	})
+
+	matches := q.config.Rule.Form(tickets, now)
+
+	// players of placing tickets stay queued, so they can't join again until placement ends.
+	for _, match := range matches {
+		for _, ticket := range match.Tickets {
+			delete(q.tickets, ticket.ID)
+			q.placing[ticket.ID] = ticket
+		}
+	}
+
+	q.mu.Unlock()
+
+	for _, ticket := range expired {
+		q.notify(Event{
+			Type:   EventTimeout,
+			Ticket: ticket,
+		})
+	}
+
+	for _, match := range matches {
+		go q.createRoom(ctx, match)
+	}
+}
+
+// createRoom creates room for match. Tickets still placing are returned to queue on failure.
+func (q *Queue) createRoom(ctx context.Context, match *Match) {
+	defer q.interval.Start("createRoom").End()
+
+	room, err := q.config.Creator.CreateRoomFromSpec(ctx, match.Spec())
+
+	q.mu.Lock()
+
+	for _, ticket := range match.Tickets {
+		// ticket left during placement.
+		if _, ok := q.placing[ticket.ID]; !ok {
+			continue
+		}
+
+		if err != nil {
+			delete(q.placing, ticket.ID)
+			q.tickets[ticket.ID] = ticket
+		} else {
+			q.remove(ticket)
+		}
+	}
+
+	q.mu.Unlock()
+
+	if err != nil {
+		q.config.Logger.Err(err).Stack().Send()
+
+		q.notify(Event{
+			Type:  EventMatchFailed,
+			Match: match,
+			Error: err,
+		})
+
+		return
+	}
+
+	q.notify(Event{
+		Type:  EventMatchFound,
+		Match: match,
+		Room:  room,
+	})
+}
+
+// Subscribe creates channel-receiver of all queue events. To close channel cancel context ctx.
+// Events are dropped while channel buffer is full.
+func (q *Queue) Subscribe(ctx context.Context) <-chan Event {
+	defer q.interval.Start("Subscribe").End()
+
+	res := make(chan Event, DefaultListenerCapacity)
+
+	q.mu.Lock()
+	q.listeners = append(q.listeners, res)
+	q.mu.Unlock()
+
+	utils.WithChannel(res).
+		BeforeClose(func() { q.removeListener(res) }).
+		AsyncCloseOnDone(ctx)
+
+	return res
+}
+
+func (q *Queue) removeListener(listener chan Event) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	index := slices.Index(q.listeners, listener)
+	if index == -1 {
+		return
+	}
+
+	q.listeners = slices.Delete(q.listeners, index, index+1)
+}
+
+func (q *Queue) notify(event Event) {
+	defer q.interval.Start("notify").End()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	utils.WithChannels(q.listeners).TryNotify(event)
+}
@@ -0,0 +1,184 @@
+package matchmaking
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/master"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCreator creates rooms with result of create, waits for release if it isn't nil.
+type testCreator struct {
+	specs   chan master.RoomSpec
+	release chan struct{}
+	err     error
+}
+
+func (c *testCreator) CreateRoomFromSpec(ctx context.Context, spec master.RoomSpec) (*proto.Room, error) {
+	c.specs <- spec
+
+	if c.release != nil {
+		<-c.release
+	}
+
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	return &proto.Room{ID: 1}, nil
+}
+
+func newTestQueue(t *testing.T, creator *testCreator) (*Queue, <-chan Event) {
+	t.Helper()
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	t.Cleanup(cancelFn)
+
+	creator.specs = make(chan master.RoomSpec, 10)
+
+	queue, err := New(Config{
+		Creator:  creator,
+		Rule:     &RatingRule{Players: 2, InitialWindow: 1000},
+		Interval: time.Millisecond,
+		Timeout:  time.Minute,
+	})
+	require.NoError(t, err)
+
+	return queue, queue.Subscribe(ctx)
+}
+
+func nextEvent(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		require.FailNow(t, "no event")
+
+		return Event{}
+	}
+}
+
+func TestQueue_JoinLeave(t *testing.T) {
+	t.Parallel()
+
+	queue, _ := newTestQueue(t, &testCreator{})
+
+	_, err := queue.Join(Ticket{})
+	require.ErrorIs(t, err, constants.ErrInvalid)
+
+	id, err := queue.Join(Ticket{Players: []uint64{1, 2}})
+	require.NoError(t, err)
+
+	_, err = queue.Join(Ticket{Players: []uint64{2}})
+	require.ErrorIs(t, err, constants.ErrInvalid, "queued player")
+	assert.Equal(t, 1, queue.Len())
+
+	require.NoError(t, queue.Leave(id))
+	require.ErrorIs(t, queue.Leave(id), constants.ErrNotFound)
+	assert.Zero(t, queue.Len())
+
+	_, err = queue.Join(Ticket{Players: []uint64{2}})
+	require.NoError(t, err, "player left")
+}
+
+func TestQueue_Serve(t *testing.T) {
+	t.Parallel()
+
+	creator := &testCreator{}
+	queue, events := newTestQueue(t, creator)
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+
+	go func() {
+		_ = queue.Serve(ctx)
+	}()
+
+	_, err := queue.Join(Ticket{Players: []uint64{1}, Rating: 1000})
+	require.NoError(t, err)
+
+	_, err = queue.Join(Ticket{Players: []uint64{2}, Rating: 1100})
+	require.NoError(t, err)
+
+	event := nextEvent(t, events)
+	require.Equal(t, EventMatchFound, event.Type)
+	assert.Equal(t, []uint64{1, 2}, event.Match.Players())
+	assert.Equal(t, uint64(1), event.Room.ID)
+	assert.Equal(t, master.RoomSpec{
+		Parties: []master.PartySpec{
+			{Clients: []uint64{1}},
+			{Clients: []uint64{2}},
+		},
+	}, <-creator.specs)
+
+	assert.Zero(t, queue.Len())
+
+	_, err = queue.Join(Ticket{Players: []uint64{1}})
+	require.NoError(t, err, "placed player")
+}
+
+func TestQueue_Timeout(t *testing.T) {
+	t.Parallel()
+
+	queue, events := newTestQueue(t, &testCreator{})
+
+	id, err := queue.Join(Ticket{Players: []uint64{1}})
+	require.NoError(t, err)
+
+	queue.pass(context.Background(), time.Now().Add(time.Minute))
+
+	event := nextEvent(t, events)
+	require.Equal(t, EventTimeout, event.Type)
+	assert.Equal(t, id, event.Ticket.ID)
+	assert.Zero(t, queue.Len())
+}
+
+func TestQueue_Requeue(t *testing.T) {
+	t.Parallel()
+
+	creator := &testCreator{
+		release: make(chan struct{}),
+		err:     constants.ErrCapacity,
+	}
+	queue, events := newTestQueue(t, creator)
+
+	left, err := queue.Join(Ticket{Players: []uint64{1}})
+	require.NoError(t, err)
+
+	stayed, err := queue.Join(Ticket{Players: []uint64{2}})
+	require.NoError(t, err)
+
+	queue.pass(context.Background(), time.Now())
+	<-creator.specs
+
+	_, err = queue.Join(Ticket{Players: []uint64{2}})
+	require.ErrorIs(t, err, constants.ErrInvalid, "placing player")
+
+	require.NoError(t, queue.Leave(left))
+
+	rejoined, err := queue.Join(Ticket{Players: []uint64{1}})
+	require.NoError(t, err, "player left placing ticket")
+
+	close(creator.release)
+
+	event := nextEvent(t, events)
+	require.Equal(t, EventMatchFailed, event.Type)
+	require.ErrorIs(t, event.Error, constants.ErrCapacity)
+
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	assert.ElementsMatch(t, []uint64{stayed, rejoined}, []uint64{
+		queue.players[1],
+		queue.players[2],
+	})
+	assert.Len(t, queue.tickets, 2, "left ticket isn't returned")
+	assert.Empty(t, queue.placing)
+}
@@ -0,0 +1,212 @@
+package matchmaking
+
+import (
+	"math"
+	"time"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/pkg/errors"
+	"golang.org/x/exp/slices"
+)
+
+// Rule forms matches from waiting tickets.
+type Rule interface {
+	// Form returns new matches. Every ticket could be used once.
+	Form(tickets []*Ticket, now time.Time) []*Match
+}
+
+// RatingRule forms matches of exactly Players players of the same region and mode.
+// Acceptable rating difference starts from InitialWindow and grows by WindowGrowth every second of waiting up to MaxWindow.
+// Players are split into Teams equal teams, parties are never split.
+type RatingRule struct {
+	Players       int
+	Teams         int // optional. No teams if zero.
+	InitialWindow float64
+	WindowGrowth  float64 // WindowGrowth is window increase per second.
+	MaxWindow     float64 // optional. Unlimited if zero.
+}
+
+// implements interface.
+var _ Rule = (*RatingRule)(nil)
+
+// validator is optional interface of Rule, checked by New.
+type validator interface {
+	Validate() error
+}
+
+// Validate returns constants.ErrInvalid if rule can't form matches.
+func (r *RatingRule) Validate() error {
+	if r.Players <= 0 {
+		return errors.Wrapf(constants.ErrInvalid, "Players %d", r.Players)
+	}
+
+	if r.Teams < 0 || r.Teams > 1 && r.Players%r.Teams != 0 {
+		return errors.Wrapf(constants.ErrInvalid, "Teams %d for %d players", r.Teams, r.Players)
+	}
+
+	// negated comparisons reject NaN.
+	if !(r.InitialWindow >= 0) {
+		return errors.Wrapf(constants.ErrInvalid, "InitialWindow %v", r.InitialWindow)
+	}
+
+	if !(r.WindowGrowth >= 0) {
+		return errors.Wrapf(constants.ErrInvalid, "WindowGrowth %v", r.WindowGrowth)
+	}
+
+	if !(r.MaxWindow >= 0) || r.MaxWindow > 0 && r.MaxWindow < r.InitialWindow {
+		return errors.Wrapf(constants.ErrInvalid, "MaxWindow %v", r.MaxWindow)
+	}
+
+	return nil
+}
+
+func (r *RatingRule) window(ticket *Ticket, now time.Time) float64 {
+	res := r.InitialWindow + r.WindowGrowth*now.Sub(ticket.Created).Seconds()
+
+	if r.MaxWindow > 0 {
+		res = math.Min(res, r.MaxWindow)
+	}
+
+	return res
+}
+
+func (r *RatingRule) Form(tickets []*Ticket, now time.Time) []*Match {
+	type pool struct {
+		region string
+		mode   string
+	}
+
+	pools := map[pool][]*Ticket{}
+
+	for _, ticket := range tickets {
+		key := pool{ticket.Region, ticket.Mode}
+		pools[key] = append(pools[key], ticket)
+	}
+
+	var res []*Match
+
+	for _, tickets := range pools {
+		res = append(res, r.formPool(tickets, now)...)
+	}
+
+	return res
+}
+
+// formPool forms matches starting from the oldest tickets.
+func (r *RatingRule) formPool(tickets []*Ticket, now time.Time) []*Match {
+	tickets = append([]*Ticket(nil), tickets...)
+
+	slices.SortFunc(tickets, func(a, b *Ticket) bool {
+		return a.Created.Before(b.Created)
+	})
+
+	used := make([]bool, len(tickets))
+
+	var res []*Match
+
+	for anchorIndex, anchor := range tickets {
+		if used[anchorIndex] || len(anchor.Players) > r.teamSize() {
+			continue
+		}
+
+		match := []*Ticket{anchor}
+		indexes := []int{anchorIndex}
+		players := len(anchor.Players)
+
+		for index := anchorIndex + 1; index < len(tickets) && players < r.Players; index++ {
+			candidate := tickets[index]
+
+			if used[index] ||
+				players+len(candidate.Players) > r.Players ||
+				len(candidate.Players) > r.teamSize() {
+				continue
+			}
+
+			// both parties should accept rating difference.
+			diff := math.Abs(anchor.Rating - candidate.Rating)
+			if diff > r.window(anchor, now) || diff > r.window(candidate, now) {
+				continue
+			}
+
+			match = append(match, candidate)
+			indexes = append(indexes, index)
+			players += len(candidate.Players)
+		}
+
+		if players != r.Players {
+			continue
+		}
+
+		teams, ok := r.assignTeams(match)
+		if !ok {
+			continue
+		}
+
+		for _, index := range indexes {
+			used[index] = true
+		}
+
+		res = append(res, &Match{
+			Tickets: match,
+			Teams:   teams,
+		})
+	}
+
+	return res
+}
+
+func (r *RatingRule) teamSize() int {
+	if r.Teams <= 1 {
+		return r.Players
+	}
+
+	return r.Players / r.Teams
+}
+
+// assignTeams places the largest parties first into team with the least players, then the least rating.
+// Returns team number of every ticket.
+func (r *RatingRule) assignTeams(tickets []*Ticket) ([]uint32, bool) {
+	if r.Teams <= 1 {
+		return nil, true
+	}
+
+	order := make([]int, len(tickets))
+	for i := range order {
+		order[i] = i
+	}
+
+	slices.SortStableFunc(order, func(a, b int) bool {
+		return len(tickets[a].Players) > len(tickets[b].Players)
+	})
+
+	players := make([]int, r.Teams)
+	rating := make([]float64, r.Teams)
+	res := make([]uint32, len(tickets))
+
+	for _, index := range order {
+		ticket := tickets[index]
+		best := -1
+
+		for team := range players {
+			if players[team]+len(ticket.Players) > r.teamSize() {
+				continue
+			}
+
+			if best == -1 ||
+				players[team] < players[best] ||
+				(players[team] == players[best] && rating[team] < rating[best]) {
+				best = team
+			}
+		}
+
+		if best == -1 {
+			return nil, false
+		}
+
+		players[best] += len(ticket.Players)
+		rating[best] += ticket.Rating * float64(len(ticket.Players))
+		res[index] = uint32(best + 1)
+	}
+
+	return res, true
+}
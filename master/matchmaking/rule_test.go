@@ -0,0 +1,106 @@
+package matchmaking
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/master"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRatingRule_Form(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	rule := RatingRule{
+		Players:       4,
+		InitialWindow: 100,
+		WindowGrowth:  10,
+		MaxWindow:     500,
+	}
+
+	tickets := []*Ticket{
+		{ID: 1, Players: []uint64{1, 2}, Rating: 1000, Region: "eu", Mode: "duo", Created: now.Add(-time.Second)},
+		{ID: 2, Players: []uint64{3}, Rating: 1050, Region: "eu", Mode: "duo", Created: now},
+		{ID: 3, Players: []uint64{4}, Rating: 1300, Region: "eu", Mode: "duo", Created: now},
+		{ID: 4, Players: []uint64{5}, Rating: 1090, Region: "eu", Mode: "duo", Created: now},
+		{ID: 5, Players: []uint64{6}, Rating: 1000, Region: "us", Mode: "duo", Created: now},
+	}
+
+	matches := rule.Form(tickets, now)
+	require.Len(t, matches, 1)
+	assert.Equal(t, []*Ticket{tickets[0], tickets[1], tickets[3]}, matches[0].Tickets)
+	assert.Nil(t, matches[0].Teams)
+
+	// window grows while waiting.
+	tickets = []*Ticket{
+		{ID: 1, Players: []uint64{1, 2, 3}, Rating: 1000, Created: now.Add(-time.Minute)},
+		{ID: 2, Players: []uint64{4}, Rating: 1400, Created: now.Add(-time.Minute)},
+	}
+
+	assert.Empty(t, rule.Form(tickets, now.Add(-time.Minute)))
+	assert.Len(t, rule.Form(tickets, now), 1)
+}
+
+func TestRatingRule_Teams(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	rule := RatingRule{
+		Players:       4,
+		Teams:         2,
+		InitialWindow: 1000,
+	}
+
+	tickets := []*Ticket{
+		{ID: 1, Players: []uint64{1}, Rating: 1200, Created: now},
+		{ID: 2, Players: []uint64{2, 3}, Rating: 1000, Created: now},
+		{ID: 3, Players: []uint64{4, 5, 6}, Rating: 1000, Created: now},
+		{ID: 4, Players: []uint64{7}, Rating: 1000, Created: now},
+	}
+
+	matches := rule.Form(tickets, now)
+	require.Len(t, matches, 1)
+
+	match := matches[0]
+	assert.Equal(t, []*Ticket{tickets[0], tickets[1], tickets[3]}, match.Tickets)
+	assert.Equal(t, []uint32{2, 1, 2}, match.Teams)
+
+	assert.Equal(t, master.RoomSpec{
+		Parties: []master.PartySpec{
+			{Team: 2, Clients: []uint64{1}},
+			{Team: 1, Clients: []uint64{2, 3}},
+			{Team: 2, Clients: []uint64{7}},
+		},
+	}, match.Spec())
+}
+
+func TestRatingRule_Validate(t *testing.T) {
+	t.Parallel()
+
+	for _, rule := range []RatingRule{
+		{},
+		{Players: -2},
+		{Players: 4, Teams: 3},
+		{Players: 4, Teams: -1},
+		{Players: 4, InitialWindow: -1},
+		{Players: 4, InitialWindow: math.NaN()},
+		{Players: 4, WindowGrowth: -1},
+		{Players: 4, InitialWindow: 100, MaxWindow: 50},
+	} {
+		rule := rule
+
+		_, err := New(Config{
+			Creator: &testCreator{},
+			Rule:    &rule,
+		})
+		require.ErrorIs(t, err, constants.ErrInvalid, "%+v", rule)
+	}
+
+	require.NoError(t, (&RatingRule{Players: 4, Teams: 2, InitialWindow: 100, MaxWindow: 100}).Validate())
+}
@@ -0,0 +1,51 @@
+package matchmaking
+
+import (
+	"time"
+
+	"github.com/opoccomaxao-go/rooms/master"
+)
+
+// Ticket is single player or party waiting for match.
+type Ticket struct {
+	ID      uint64    // ID is assigned by Queue.Join.
+	Players []uint64  // Players are party members, always placed into the same match and team.
+	Rating  float64   // Rating of party.
+	Region  string    // Region of party, matches are formed within the same region.
+	Mode    string    // Mode is game mode, matches are formed within the same mode.
+	Created time.Time // Created is set by Queue.Join.
+}
+
+// Match is group of tickets placed into the same room.
+type Match struct {
+	Tickets []*Ticket
+	Teams   []uint32 // Teams contains team number of every ticket, nil if there are no teams.
+}
+
+// Players returns all players of match.
+func (m *Match) Players() []uint64 {
+	var res []uint64
+
+	for _, ticket := range m.Tickets {
+		res = append(res, ticket.Players...)
+	}
+
+	return res
+}
+
+// Spec returns room spec with every ticket as party.
+func (m *Match) Spec() master.RoomSpec {
+	res := master.RoomSpec{
+		Parties: make([]master.PartySpec, len(m.Tickets)),
+	}
+
+	for index, ticket := range m.Tickets {
+		res.Parties[index].Clients = ticket.Players
+
+		if m.Teams != nil {
+			res.Parties[index].Team = m.Teams[index]
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,120 @@
+package master
+
+import (
+	"strconv"
+
+	"github.com/opoccomaxao-go/rooms/apm"
+)
+
+type metrics struct {
+	roomsCreated  *apm.Counter
+	roomsFailed   *apm.Counter
+	roomsFinished *apm.Counter
+	createLatency *apm.Histogram
+}
+
+// Metrics returns registry of master metrics.
+func (s *Server) Metrics() *apm.Registry {
+	return s.config.Metrics
+}
+
+func (s *Server) initMetrics() {
+	registry := s.config.Metrics
+
+	s.metrics = metrics{
+		roomsCreated: registry.Counter(
+			"rooms_master_rooms_created_total", "Count of created rooms.",
+		),
+		roomsFailed: registry.Counter(
+			"rooms_master_rooms_failed_total", "Count of room requests failed after all attempts.",
+		),
+		roomsFinished: registry.Counter(
+			"rooms_master_rooms_finished_total", "Count of finished rooms.",
+		),
+		createLatency: registry.Histogram(
+			"rooms_master_room_create_duration_seconds", "Duration of room creation including retries.", nil,
+		),
+	}
+
+	registry.GaugeFunc(
+		"rooms_master_servers_connected", "Count of connected session servers.",
+		nil, s.serversConnectedSamples,
+	)
+	registry.GaugeFunc(
+		"rooms_master_server_capacity", "Capacity reported by session server.",
+		[]string{"server"}, s.serverCapacitySamples,
+	)
+	registry.GaugeFunc(
+		"rooms_master_rooms_active", "Count of active rooms.",
+		nil, s.roomsActiveSamples,
+	)
+	registry.GaugeFunc(
+		"rooms_master_finished_listeners_queue_depth", "Count of finished rooms buffered in FinishedRooms listeners.",
+		nil, s.listenersQueueSamples,
+	)
+}
+
+// observePlacement records result of finished placement and ends its span.
+func (s *Server) observePlacement(p *placement) {
+	s.metrics.createLatency.ObserveDuration(p.duration())
+
+	p.span.SetError(p.result.Error)
+	p.span.End()
+
+	if p.result.Error != nil {
+		s.metrics.roomsFailed.Inc()
+	} else {
+		s.metrics.roomsCreated.Inc()
+	}
+}
+
+func (s *Server) serversConnectedSamples() []apm.Sample {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := 0
+
+	for _, conn := range s.clients {
+		if !conn.isLost() {
+			res++
+		}
+	}
+
+	return []apm.Sample{{Value: float64(res)}}
+}
+
+func (s *Server) serverCapacitySamples() []apm.Sample {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := make([]apm.Sample, 0, len(s.clients))
+
+	for id, conn := range s.clients {
+		res = append(res, apm.Sample{
+			LabelValues: []string{strconv.FormatUint(id, 10)},
+			Value:       float64(conn.capacity()),
+		})
+	}
+
+	return res
+}
+
+func (s *Server) roomsActiveSamples() []apm.Sample {
+	s.roomsMu.RLock()
+	defer s.roomsMu.RUnlock()
+
+	return []apm.Sample{{Value: float64(len(s.rooms))}}
+}
+
+func (s *Server) listenersQueueSamples() []apm.Sample {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := 0
+
+	for _, listener := range s.listenersFinished {
+		res += len(listener)
+	}
+
+	return []apm.Sample{{Value: float64(res)}}
+}
@@ -0,0 +1,162 @@
+package master
+
+import (
+	"context"
+	"time"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/opoccomaxao-go/rooms/storage"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultOutboxCapacity = 10000
+	outboxReadBatch       = 100
+	outboxRetryInterval   = time.Millisecond * 100 // outboxRetryInterval is pause of consumer after skipped room.
+)
+
+// FinishedRoom is finished room with its outbox offset.
+type FinishedRoom struct {
+	Offset uint64
+	Room   *proto.Room
+}
+
+type ConsumerConfig struct {
+	Name     string         // Name identifies consumer offset in Outbox.
+	Capacity int            // optional. Default = DefaultRoomListenerCapacity
+	Overflow OverflowPolicy // Overflow is slow consumer policy. Default = OverflowDrop
+	Timeout  time.Duration  // optional. Timeout for OverflowBlock. Default = constants.DefaultTimeout
+}
+
+// Consumer receives finished rooms from outbox starting after last acknowledged offset.
+// Rooms skipped by overflow policy are delivered again in order after pause,
+// unacknowledged rooms are delivered again after resubscription.
+type Consumer struct {
+	C <-chan FinishedRoom
+
+	config ConsumerConfig
+	parent *Server
+}
+
+// Ack marks all rooms up to offset as processed.
+func (c *Consumer) Ack(offset uint64) error {
+	return errors.WithStack(c.parent.config.Outbox.Ack(c.config.Name, offset))
+}
+
+// ConsumeFinishedRooms creates durable consumer of finished rooms. To close channel cancel context ctx.
+func (s *Server) ConsumeFinishedRooms(ctx context.Context, cfg ConsumerConfig) (*Consumer, error) {
+	defer s.interval.Start("ConsumeFinishedRooms").End()
+
+	if cfg.Name == "" {
+		return nil, errors.WithMessage(constants.ErrNoParam, "Name")
+	}
+
+	if cfg.Capacity <= 0 {
+		cfg.Capacity = DefaultRoomListenerCapacity
+	}
+
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = constants.DefaultTimeout
+	}
+
+	offset, err := s.config.Outbox.Offset(cfg.Name)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	channel := make(chan FinishedRoom, cfg.Capacity)
+
+	res := &Consumer{
+		C:      channel,
+		config: cfg,
+		parent: s,
+	}
+
+	go s.serveConsumer(ctx, res, channel, offset+1)
+
+	return res, nil
+}
+
+func (s *Server) serveConsumer(ctx context.Context, consumer *Consumer, channel chan FinishedRoom, next uint64) {
+	defer s.interval.Start("serveConsumer").End()
+	defer close(channel)
+
+	for {
+		signal := s.outboxUpdated.Wait()
+
+		entries, err := s.config.Outbox.Read(next, outboxReadBatch)
+		if err != nil {
+			s.config.Logger.Err(errors.WithStack(err)).Stack().Send()
+
+			return
+		}
+
+		if len(entries) == 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signal:
+				continue
+			}
+		}
+
+		var ok bool
+
+		next, ok = deliverEntries(ctx, consumer, channel, entries, next)
+		if !ok {
+			return
+		}
+	}
+}
+
+// deliverEntries sends entries until first skipped one. Returns offset of next undelivered entry
+// and false if consumer should be disconnected.
+func deliverEntries(
+	ctx context.Context,
+	consumer *Consumer,
+	channel chan FinishedRoom,
+	entries []*storage.OutboxEntry,
+	next uint64,
+) (uint64, bool) {
+	for _, entry := range entries {
+		delivered, keep := tryDeliver(ctx, channel, FinishedRoom{
+			Offset: entry.Offset,
+			Room:   entry.Room,
+		}, consumer.config.Overflow, consumer.config.Timeout)
+		if !keep {
+			return next, false
+		}
+
+		if !delivered {
+			// room is retried from same offset, later rooms aren't delivered before it.
+			timer := time.NewTimer(outboxRetryInterval)
+			defer timer.Stop()
+
+			select {
+			case <-ctx.Done():
+				return next, false
+			case <-timer.C:
+				return next, true
+			}
+		}
+
+		next = entry.Offset + 1
+	}
+
+	return next, true
+}
+
+// appendOutbox stores finished room for consumers. Room must not be acknowledged to session server on error.
+func (s *Server) appendOutbox(room *proto.Room) error {
+	defer s.interval.Start("appendOutbox").End()
+
+	_, err := s.config.Outbox.Append(room)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	s.outboxUpdated.Broadcast()
+
+	return nil
+}
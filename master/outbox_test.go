@@ -0,0 +1,70 @@
+package master
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/opoccomaxao-go/rooms/storage"
+	"github.com/opoccomaxao-go/rooms/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_ConsumeFinishedRooms(t *testing.T) {
+	t.Parallel()
+
+	for name, policy := range map[string]OverflowPolicy{
+		"Drop":  OverflowDrop,
+		"Block": OverflowBlock,
+	} {
+		policy := policy
+
+		t.Run(name, func(t *testing.T) {
+			testConsumeFinishedRooms(t, policy)
+		})
+	}
+}
+
+func testConsumeFinishedRooms(t *testing.T, policy OverflowPolicy) {
+	t.Helper()
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+
+	server := newTestServer()
+	server.config.Outbox = storage.NewRAMOutbox(DefaultOutboxCapacity)
+	server.outboxUpdated = utils.NewSignal()
+
+	for id := uint64(1); id <= 5; id++ {
+		server.appendOutbox(&proto.Room{ID: id})
+	}
+
+	legacy := server.FinishedRooms(ctx)
+
+	consumer, err := server.ConsumeFinishedRooms(ctx, ConsumerConfig{
+		Name:     "test",
+		Capacity: 1,
+		Overflow: policy,
+		Timeout:  time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	// slow consumer gets every room in order, skipped ones are retried.
+	for id := uint64(1); id <= 5; id++ {
+		time.Sleep(time.Millisecond * 10)
+
+		finished := <-consumer.C
+		assert.Equal(t, id, finished.Offset)
+		assert.Equal(t, id, finished.Room.ID)
+		require.NoError(t, consumer.Ack(finished.Offset))
+	}
+
+	// full legacy listener doesn't block finished rooms.
+	for id := uint64(6); id < 6+DefaultRoomListenerCapacity+1; id++ {
+		server.notifyFinishedRoom(&proto.Room{ID: id})
+	}
+
+	assert.Len(t, legacy, DefaultRoomListenerCapacity)
+}
@@ -0,0 +1,64 @@
+package master
+
+import (
+	"context"
+	"time"
+)
+
+// OverflowPolicy defines behaviour on full subscriber buffer.
+type OverflowPolicy int
+
+const (
+	// OverflowDrop skips value if buffer is full.
+	OverflowDrop OverflowPolicy = iota
+	// OverflowBlock waits up to timeout, then skips value.
+	OverflowBlock
+	// OverflowDisconnect closes subscriber channel if buffer is full.
+	OverflowDisconnect
+)
+
+// deliver sends value to channel according to policy. Returns false if subscriber should be disconnected.
+func deliver[T any](ctx context.Context, channel chan<- T, value T, policy OverflowPolicy, timeout time.Duration) bool {
+	_, keep := tryDeliver(ctx, channel, value, policy, timeout)
+
+	return keep
+}
+
+// tryDeliver sends value to channel according to policy.
+// Returns whether value is delivered and false keep if subscriber should be disconnected.
+func tryDeliver[T any](
+	ctx context.Context,
+	channel chan<- T,
+	value T,
+	policy OverflowPolicy,
+	timeout time.Duration,
+) (delivered bool, keep bool) {
+	select {
+	case channel <- value:
+		return true, true
+	case <-ctx.Done():
+		return false, false
+	default:
+	}
+
+	switch policy {
+	case OverflowBlock:
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		select {
+		case channel <- value:
+			return true, true
+		case <-timer.C:
+			return false, true
+		case <-ctx.Done():
+			return false, false
+		}
+	case OverflowDisconnect:
+		return false, false
+	case OverflowDrop:
+		return false, true
+	default:
+		return false, true
+	}
+}
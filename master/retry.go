@@ -0,0 +1,165 @@
+package master
+
+import (
+	"context"
+	"time"
+
+	"github.com/opoccomaxao-go/rooms/apm"
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/opoccomaxao-go/rooms/storage"
+	"github.com/pkg/errors"
+)
+
+// placement keeps retry state of one room.
+type placement struct {
+	room     *proto.Room
+	attempts int
+	excluded map[*connWrapper]struct{} // excluded contains servers failed to create room.
+	lastErr  error
+	result   RoomCreateResult
+	started  time.Time
+	traceCtx context.Context // traceCtx contains root span of room creation.
+	span     apm.Span
+}
+
+func newPlacement(room *proto.Room) *placement {
+	return &placement{
+		room:     room,
+		excluded: map[*connWrapper]struct{}{},
+		started:  time.Now(),
+	}
+}
+
+func (p *placement) duration() time.Duration {
+	return time.Since(p.started)
+}
+
+// isRetryable returns true if room could be created on another session server after err.
+func isRetryable(err error) bool {
+	var roomErr *proto.Error
+	if errors.As(err, &roomErr) {
+		return roomErr.Retryable()
+	}
+
+	return errors.Is(err, constants.ErrServerLost) || errors.Is(err, constants.ErrTimeout)
+}
+
+// applyCreateResult handles result of room creation attempt on server. Returns true if placement is finished.
+func (s *Server) applyCreateResult(ctx context.Context, server *connWrapper, p *placement, res RoomCreateResult) bool {
+	ctx = apm.ContextWithServerID(apm.ContextWithRoomID(ctx, p.room.ID), server.id)
+
+	ctx, interval := apm.StartContext(ctx, s.interval, "applyCreateResult")
+	defer interval.End()
+
+	p.attempts++
+
+	if res.Room != nil && res.Error == nil {
+		server.succeed()
+
+		res.Room.ServerID = server.id
+
+		s.addRoom(res.Room)
+		s.publishRoom(EventRoomCreated, server.id, res.Room, nil)
+
+		s.saveRoomEvent(&storage.RoomEvent{
+			Status:   storage.RoomStatusCreated,
+			RoomID:   p.room.ID,
+			ServerID: server.id,
+		})
+
+		p.result = res
+
+		return true
+	}
+
+	server.release(p.room.ID)
+
+	err := res.Error
+	if err == nil || errors.Is(err, constants.ErrServerLost) {
+		// server could create room after timeout or before lost connection.
+		s.cancelRoom(server, p.room.ID)
+	}
+
+	if err == nil {
+		if ctx.Err() != nil {
+			s.expirePlacement(p, ctx.Err())
+
+			return true
+		}
+
+		err = errors.WithStack(constants.ErrTimeout)
+	}
+
+	s.saveRoomError(p.room.ID, server.id, err)
+	s.publishRoom(EventRoomError, server.id, p.room, err)
+
+	p.lastErr = err
+
+	if !isRetryable(err) {
+		p.result.Error = err
+
+		return true
+	}
+
+	// failed server is excluded from scheduling of other rooms after last attempt too.
+	server.fail(s.config.RetryBackoff, s.config.MaxRetryBackoff)
+
+	p.excluded[server] = struct{}{}
+
+	if p.attempts >= s.config.MaxAttempts {
+		p.result.Error = err
+
+		return true
+	}
+
+	return false
+}
+
+// expirePlacement finishes placement without free server. Last attempt error is preferred over err.
+func (s *Server) expirePlacement(p *placement, err error) {
+	if p.lastErr != nil {
+		p.result.Error = p.lastErr
+
+		return
+	}
+
+	s.saveRoomError(p.room.ID, 0, err)
+	s.publishRoom(EventRoomError, 0, p.room, err)
+
+	p.result.Error = err
+}
+
+// fail excludes server from scheduling with exponential backoff.
+func (c *connWrapper) fail(backoff time.Duration, maxBackoff time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.failures < 32 {
+		c.failures++
+	}
+
+	delay := backoff << (c.failures - 1)
+	if delay > maxBackoff || delay <= 0 {
+		delay = maxBackoff
+	}
+
+	c.backoffUntil = time.Now().Add(delay)
+}
+
+// succeed resets backoff of server.
+func (c *connWrapper) succeed() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.failures = 0
+	c.backoffUntil = time.Time{}
+}
+
+// inBackoff returns true if server recently failed to create room.
+func (c *connWrapper) inBackoff(now time.Time) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return now.Before(c.backoffUntil)
+}
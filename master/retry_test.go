@@ -0,0 +1,92 @@
+package master
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/opoccomaxao-go/ipc/event"
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsRetryable(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, isRetryable(errors.WithStack(&proto.Error{Code: proto.ErrorCodeCapacity})))
+	assert.True(t, isRetryable(errors.WithStack(constants.ErrServerLost)))
+	assert.True(t, isRetryable(errors.WithStack(constants.ErrTimeout)))
+	assert.False(t, isRetryable(errors.WithStack(&proto.Error{Code: proto.ErrorCodeInvalid})))
+	assert.False(t, isRetryable(errors.WithStack(&proto.Error{Code: proto.ErrorCodeEngineInit})))
+	assert.False(t, isRetryable(errors.New("unknown")))
+}
+
+func TestConnWrapper_Backoff(t *testing.T) {
+	t.Parallel()
+
+	conn := connWrapper{}
+	now := time.Now()
+
+	assert.False(t, conn.inBackoff(now))
+
+	conn.fail(time.Hour, 3*time.Hour)
+	assert.True(t, conn.inBackoff(now.Add(time.Hour-time.Minute)))
+	assert.False(t, conn.inBackoff(now.Add(time.Hour+time.Minute)))
+
+	conn.fail(time.Hour, 3*time.Hour)
+	assert.True(t, conn.inBackoff(now.Add(2*time.Hour-time.Minute)), "doubled")
+
+	conn.fail(time.Hour, 3*time.Hour)
+	assert.False(t, conn.inBackoff(now.Add(3*time.Hour+time.Minute)), "limited")
+
+	conn.succeed()
+	assert.False(t, conn.inBackoff(now))
+}
+
+func TestServer_ApplyCreateResult(t *testing.T) {
+	t.Parallel()
+
+	for name, test := range map[string]struct {
+		err       error
+		cancelled bool
+	}{
+		"Capacity": {err: errors.WithStack(&proto.Error{Code: proto.ErrorCodeCapacity})},
+		"Timeout":  {cancelled: true},
+		"Lost":     {err: errors.WithStack(constants.ErrServerLost), cancelled: true},
+	} {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := newTestServer()
+			server.config.MaxAttempts = 1
+			server.config.RetryBackoff = time.Hour
+			server.config.MaxRetryBackoff = time.Hour
+
+			var sent []*event.Common
+
+			conn := newTestConn(server, 1, &sent)
+			conn.ledger = newLedger(time.Hour)
+
+			p := newPlacement(&proto.Room{ID: 10})
+
+			done := server.applyCreateResult(context.Background(), conn, p, RoomCreateResult{Error: test.err})
+			assert.True(t, done, "last attempt")
+			require.Error(t, p.result.Error)
+			assert.True(t, conn.inBackoff(time.Now()), "backoff after last attempt")
+
+			if test.cancelled {
+				require.Len(t, sent, 1)
+				assert.Equal(t, proto.CommandMasterRoomCancel, sent[0].Type)
+				assert.Equal(t, map[uint64]uint64{10: 1}, server.cancels)
+			} else {
+				assert.Empty(t, sent, "session server reported failure")
+				assert.Empty(t, server.cancels)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package master
+
+import (
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/opoccomaxao-go/rooms/storage"
+	"github.com/pkg/errors"
+	"golang.org/x/exp/maps"
+)
+
+// finishedHistory is count of last finished rooms kept to skip duplicate results.
+const finishedHistory = DefaultOutboxCapacity
+
+// Rooms returns all active rooms.
+func (s *Server) Rooms() []*proto.Room {
+	defer s.interval.Start("Rooms").End()
+
+	s.roomsMu.RLock()
+	defer s.roomsMu.RUnlock()
+
+	return maps.Values(s.rooms)
+}
+
+// Room returns active room or constants.ErrNotFound.
+func (s *Server) Room(roomID uint64) (*proto.Room, error) {
+	defer s.interval.Start("Room").End()
+
+	s.roomsMu.RLock()
+	defer s.roomsMu.RUnlock()
+
+	room, ok := s.rooms[roomID]
+	if !ok {
+		return nil, errors.Wrapf(constants.ErrNotFound, "room %d", roomID)
+	}
+
+	return room, nil
+}
+
+// CancelRoom requests session server to stop active room.
+func (s *Server) CancelRoom(roomID uint64) error {
+	defer s.interval.Start("CancelRoom").End()
+
+	s.roomsMu.Lock()
+	room, ok := s.rooms[roomID]
+	delete(s.rooms, roomID)
+	s.roomsMu.Unlock()
+
+	if !ok {
+		return errors.Wrapf(constants.ErrNotFound, "room %d", roomID)
+	}
+
+	s.saveRoomEvent(&storage.RoomEvent{
+		Status:   storage.RoomStatusCancelled,
+		RoomID:   roomID,
+		ServerID: room.ServerID,
+	})
+
+	s.publishRoom(EventRoomCancelled, room.ServerID, room, nil)
+
+	conn, ok := s.client(room.ServerID)
+	if !ok {
+		s.roomsMu.Lock()
+		s.cancels[roomID] = room.ServerID
+		s.roomsMu.Unlock()
+
+		return nil
+	}
+
+	s.cancelRoom(conn, roomID)
+	s.checkDrained(conn)
+
+	return nil
+}
+
+// cancelRoom sends RoomCancel and keeps it pending until session server reports room absence.
+func (s *Server) cancelRoom(conn *connWrapper, roomID uint64) {
+	defer s.interval.Start("cancelRoom").End()
+
+	s.roomsMu.Lock()
+	s.cancels[roomID] = conn.id
+	s.roomsMu.Unlock()
+
+	conn.RoomCancel(roomID)
+}
+
+func (s *Server) addRoom(room *proto.Room) {
+	defer s.interval.Start("addRoom").End()
+
+	s.roomsMu.Lock()
+	defer s.roomsMu.Unlock()
+
+	s.rooms[room.ID] = room
+}
+
+// acceptResult checks result of room. Returns false if room is already finished or unknown to synced or anonymous server.
+func (s *Server) acceptResult(conn *connWrapper, roomID uint64) bool {
+	defer s.interval.Start("acceptResult").End()
+
+	s.roomsMu.RLock()
+	defer s.roomsMu.RUnlock()
+
+	if _, ok := s.finished[roomID]; ok {
+		return false
+	}
+
+	// room could be unknown after master restart, result is accepted from authenticated server until inventory.
+	if _, ok := s.rooms[roomID]; !ok && (conn.id == 0 || conn.synced) {
+		return false
+	}
+
+	return true
+}
+
+// finishRoom removes finished room and remembers it to detect resent results.
+func (s *Server) finishRoom(roomID uint64) {
+	defer s.interval.Start("finishRoom").End()
+
+	s.roomsMu.Lock()
+	defer s.roomsMu.Unlock()
+
+	delete(s.rooms, roomID)
+	delete(s.cancels, roomID)
+
+	s.finished[roomID] = struct{}{}
+	s.finishedIDs = append(s.finishedIDs, roomID)
+
+	if len(s.finishedIDs) > finishedHistory {
+		delete(s.finished, s.finishedIDs[0])
+		s.finishedIDs = s.finishedIDs[1:]
+	}
+}
+
+// reconcile synchronizes rooms of session server with its inventory:
+// unknown rooms are adopted, missing rooms are lost, pending cancels are sent again.
+func (s *Server) reconcile(conn *connWrapper, inventory []*proto.Room) {
+	defer s.interval.Start("reconcile").End()
+
+	running := make(map[uint64]*proto.Room, len(inventory))
+	for _, room := range inventory {
+		running[room.ID] = room
+	}
+
+	var (
+		adopted, cancels []uint64
+		lost             []*proto.Room
+	)
+
+	s.roomsMu.Lock()
+
+	for id, serverID := range s.cancels {
+		if serverID != conn.id {
+			continue
+		}
+
+		if _, ok := running[id]; ok {
+			cancels = append(cancels, id)
+		} else {
+			delete(s.cancels, id)
+		}
+	}
+
+	for id, room := range s.rooms {
+		if _, ok := running[id]; !ok && room.ServerID == conn.id {
+			lost = append(lost, room)
+			delete(s.rooms, id)
+		}
+	}
+
+	for id, room := range running {
+		if _, ok := s.cancels[id]; ok {
+			continue
+		}
+
+		if _, ok := s.rooms[id]; !ok {
+			room.ServerID = conn.id
+			s.rooms[id] = room
+			adopted = append(adopted, id)
+		}
+	}
+
+	s.roomsMu.Unlock()
+
+	for _, id := range adopted {
+		s.saveRoomEvent(&storage.RoomEvent{
+			Status:   storage.RoomStatusCreated,
+			RoomID:   id,
+			ServerID: conn.id,
+			Clients:  clientIDs(running[id]),
+		})
+
+		s.publishRoom(EventRoomCreated, conn.id, running[id], nil)
+	}
+
+	for _, room := range lost {
+		s.saveRoomEvent(&storage.RoomEvent{
+			Status:   storage.RoomStatusLost,
+			RoomID:   room.ID,
+			ServerID: conn.id,
+		})
+
+		s.publishRoom(EventRoomLost, conn.id, room, nil)
+	}
+
+	for _, id := range cancels {
+		conn.RoomCancel(id)
+	}
+}
+
+func clientIDs(room *proto.Room) []uint64 {
+	res := make([]uint64, len(room.Clients))
+
+	for i, client := range room.Clients {
+		res[i] = client.ID
+	}
+
+	return res
+}
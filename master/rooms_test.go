@@ -0,0 +1,98 @@
+package master
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/opoccomaxao-go/ipc/event"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/opoccomaxao-go/rooms/reliable"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/maps"
+)
+
+// newTestConn creates connection of server, sent reliable commands are recorded.
+func newTestConn(server *Server, id uint64, sent *[]*event.Common) *connWrapper {
+	res := &connWrapper{
+		parent:   server,
+		id:       id,
+		interval: server.interval,
+	}
+
+	res.sender = reliable.NewSender(func(event *event.Common) error {
+		*sent = append(*sent, event.Copy())
+
+		return nil
+	}, nil)
+
+	return res
+}
+
+func TestServer_Reconcile(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+
+	server := newTestServer()
+	events := server.Subscribe(ctx, SubscriberConfig{
+		Filter:   EventFilter{Types: EventRoom},
+		Capacity: 10,
+	})
+
+	var sent []*event.Common
+
+	conn := newTestConn(server, 1, &sent)
+
+	server.addRoom(&proto.Room{ID: 1, ServerID: 1})
+	server.addRoom(&proto.Room{ID: 2, ServerID: 1})
+	server.addRoom(&proto.Room{ID: 3, ServerID: 2})
+	server.cancels[4] = 1
+	server.cancels[5] = 1
+	server.cancels[7] = 2
+
+	server.reconcile(conn, []*proto.Room{
+		{ID: 1},
+		{ID: 4},
+		{ID: 6, Clients: []*proto.Client{{ID: 10}}},
+	})
+
+	t.Run("Adopted", func(t *testing.T) {
+		room, err := server.Room(6)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1), room.ServerID)
+
+		event := <-events
+		assert.Equal(t, EventRoomCreated, event.Type)
+		assert.Equal(t, uint64(6), event.Room.ID)
+	})
+
+	t.Run("Lost", func(t *testing.T) {
+		_, err := server.Room(2)
+		require.Error(t, err)
+
+		event := <-events
+		assert.Equal(t, EventRoomLost, event.Type)
+		assert.Equal(t, uint64(2), event.Room.ID)
+	})
+
+	t.Run("Cancels", func(t *testing.T) {
+		require.Len(t, sent, 1)
+		assert.Equal(t, proto.CommandMasterRoomCancel, sent[0].Type)
+
+		_, body, err := reliable.ReadHeader(sent[0].Payload)
+		require.NoError(t, err)
+
+		id, err := proto.ReadID(body)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(4), id)
+
+		assert.Equal(t, map[uint64]uint64{4: 1, 7: 2}, server.cancels, "absent room isn't pending")
+	})
+
+	ids := maps.Keys(server.rooms)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	assert.Equal(t, []uint64{1, 3, 6}, ids)
+}
@@ -2,6 +2,8 @@ package master
 
 import (
 	"context"
+	"net"
+	"net/http"
 	"sync"
 	"time"
 
@@ -22,20 +24,69 @@ type Server struct {
 	config   Config
 	interval apm.DebuggableInterval
 
-	server  *channel.Server
-	clients map[uint64]*connWrapper
+	server        *channel.Server
+	admin         *http.Server
+	metricsServer *http.Server // metricsServer is Prometheus metrics HTTP listener.
+	metrics       metrics
+	clients       map[uint64]*connWrapper
+	drains        map[uint64]struct{} // drains contains ids of servers excluded from scheduling.
 
-	condStats         *sync.Cond
+	statsUpdated      *utils.Signal
 	listenersFinished []chan *proto.Room
 
+	outboxUpdated *utils.Signal
+
+	subscribers   []*subscriber
+	subscribersMu sync.RWMutex
+
+	rooms       map[uint64]*proto.Room
+	cancels     map[uint64]uint64   // cancels contains server id of room with pending cancel.
+	finished    map[uint64]struct{} // finished contains ids of last finishedHistory finished rooms.
+	finishedIDs []uint64            // finishedIDs is order of finished rooms.
+	roomsMu     sync.RWMutex
+
 	mu sync.RWMutex
 }
 
 type Config struct {
 	Logger         *zerolog.Logger
+	Intervals      apm.IntervalFactory // optional. Measures methods. Default = apm.ZerologIntervals(Logger)
+	Tracer         apm.Tracer          // optional. Traces room creation. Default = apm.NopTracer()
 	Storage        storage.Storage
-	SessionAddress string        // SessionAddress is address for session-server listening.
-	CreateTimeout  time.Duration // CreateTimeout is NewRoom timeout.
+	RoomStore      storage.RoomStore // optional. Rooms history isn't persisted if nil.
+	Outbox         storage.Outbox    // optional. Default = storage.NewRAMOutbox(DefaultOutboxCapacity)
+	SessionAddress string            // SessionAddress is address for session-server listening.
+	CreateTimeout  time.Duration     // CreateTimeout is NewRoom timeout.
+
+	// MaxAttempts is max count of servers tried for one room. Default = constants.DefaultMaxAttempts
+	MaxAttempts int
+	// AttemptTimeout is timeout of room creation on one server. Default = CreateTimeout / MaxAttempts
+	AttemptTimeout time.Duration
+	// RetryBackoff is initial scheduling exclusion of failed server, doubled on every next failure.
+	// Default = constants.DefaultRetryBackoff
+	RetryBackoff time.Duration
+	// MaxRetryBackoff is max scheduling exclusion of failed server. Default = constants.DefaultMaxRetryBackoff
+	MaxRetryBackoff time.Duration
+	// Metrics is registry of master metrics. Default = apm.NewRegistry()
+	Metrics *apm.Registry
+	// MetricsAddress is address of Prometheus metrics HTTP listener. Listener is disabled if empty.
+	MetricsAddress string
+	// AdminAddress is address of HTTP/JSON admin API. Admin API is disabled if empty.
+	AdminAddress string
+	// AdminToken is bearer token of admin API. Required if AdminAddress is set.
+	AdminToken string
+	// ReservationTimeout is lifetime of reserved session server slot. Default = CreateTimeout
+	ReservationTimeout time.Duration
+	// HeartbeatInterval is interval between heartbeats. Default = constants.DefaultHeartbeatInterval
+	HeartbeatInterval time.Duration
+	// HeartbeatMisses is count of missed heartbeats before disconnect. Default = constants.DefaultHeartbeatMisses
+	HeartbeatMisses int
+	// LostTimeout is grace period for disconnected session server to reconnect before its rooms are lost.
+	// Default = constants.DefaultTimeout
+	LostTimeout time.Duration
+	// Commands are handlers of custom commands from session servers, ids are in custom range of proto.
+	// optional. Custom commands are ignored if nil.
+	Commands map[uint16]CommandHandler
 }
 
 func New(cfg Config) (*Server, error) {
@@ -46,10 +97,22 @@ func New(cfg Config) (*Server, error) {
 		cfg.Logger = &logger
 	}
 
+	if cfg.Tracer == nil {
+		cfg.Tracer = apm.NopTracer()
+	}
+
+	if cfg.Intervals == nil {
+		cfg.Intervals = apm.ZerologIntervals(cfg.Logger)
+	}
+
 	if cfg.Storage == nil {
 		cfg.Storage = storage.NewRAM()
 	}
 
+	if cfg.Outbox == nil {
+		cfg.Outbox = storage.NewRAMOutbox(DefaultOutboxCapacity)
+	}
+
 	if cfg.SessionAddress == "" {
 		cfg.SessionAddress = constants.DefaultAddress
 	}
@@ -58,11 +121,82 @@ func New(cfg Config) (*Server, error) {
 		cfg.CreateTimeout = constants.DefaultTimeout
 	}
 
+	for command := range cfg.Commands {
+		err = proto.ValidateCustomCommand(command, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.AdminAddress != "" && cfg.AdminToken == "" {
+		return nil, errors.WithMessage(constants.ErrNoParam, "AdminToken")
+	}
+
+	if cfg.Metrics == nil {
+		cfg.Metrics = apm.NewRegistry()
+	}
+
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = constants.DefaultMaxAttempts
+	}
+
+	if cfg.AttemptTimeout <= 0 {
+		cfg.AttemptTimeout = cfg.CreateTimeout / time.Duration(cfg.MaxAttempts)
+	}
+
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = constants.DefaultRetryBackoff
+	}
+
+	if cfg.MaxRetryBackoff <= 0 {
+		cfg.MaxRetryBackoff = constants.DefaultMaxRetryBackoff
+	}
+
+	if cfg.ReservationTimeout <= 0 {
+		cfg.ReservationTimeout = cfg.CreateTimeout
+	}
+
+	if cfg.HeartbeatInterval <= 0 {
+		cfg.HeartbeatInterval = constants.DefaultHeartbeatInterval
+	}
+
+	if cfg.HeartbeatMisses <= 0 {
+		cfg.HeartbeatMisses = constants.DefaultHeartbeatMisses
+	}
+
+	if cfg.LostTimeout <= 0 {
+		cfg.LostTimeout = constants.DefaultTimeout
+	}
+
 	res := &Server{
-		config:    cfg,
-		interval:  apm.NewZerologInterval(cfg.Logger, "master.Server."),
-		clients:   map[uint64]*connWrapper{},
-		condStats: sync.NewCond(&sync.Mutex{}),
+		config:        cfg,
+		interval:      cfg.Intervals("master.Server."),
+		clients:       map[uint64]*connWrapper{},
+		drains:        map[uint64]struct{}{},
+		statsUpdated:  utils.NewSignal(),
+		outboxUpdated: utils.NewSignal(),
+
+		rooms:    map[uint64]*proto.Room{},
+		cancels:  map[uint64]uint64{},
+		finished: map[uint64]struct{}{},
+	}
+
+	res.initMetrics()
+
+	if cfg.AdminAddress != "" {
+		res.admin = &http.Server{
+			Addr:              cfg.AdminAddress,
+			Handler:           res.AdminHandler(),
+			ReadHeaderTimeout: constants.DefaultTimeout,
+		}
+	}
+
+	if cfg.MetricsAddress != "" {
+		res.metricsServer = &http.Server{
+			Addr:              cfg.MetricsAddress,
+			Handler:           cfg.Metrics.Handler(),
+			ReadHeaderTimeout: constants.DefaultTimeout,
+		}
 	}
 
 	res.server, err = channel.NewServer(channel.ServerConfig{
@@ -86,6 +220,10 @@ func (s *Server) handle(conn *channel.Channel) {
 
 	server.init()
 
+	s.publish(Event{
+		Type: EventServerConnected,
+	})
+
 	server.Serve()
 }
 
@@ -95,7 +233,8 @@ func (s *Server) register(id uint64, client *connWrapper) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if prev, ok := s.clients[id]; ok {
+	// repeated Auth on same connection keeps it open.
+	if prev, ok := s.clients[id]; ok && prev != client {
 		err := errors.Wrap(client.FlushInstance(prev), "flush error")
 		if err != nil {
 			s.config.Logger.Err(err).Stack().Send()
@@ -118,6 +257,16 @@ func (s *Server) unregister(id uint64, client *connWrapper) {
 	}
 }
 
+func (s *Server) client(id uint64) (*connWrapper, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res, ok := s.clients[id]
+
+	return res, ok
+}
+
+// Serve accepts session servers until Close or ctx is done. Returns nil after Close.
 func (s *Server) Serve(ctx context.Context) error {
 	defer s.interval.Start("Serve").End()
 
@@ -129,104 +278,205 @@ func (s *Server) Serve(ctx context.Context) error {
 			}
 		})
 
-	return errors.WithStack(s.server.Listen())
+	for _, server := range []*http.Server{s.admin, s.metricsServer} {
+		if server != nil {
+			go s.serveHTTP(server)
+		}
+	}
+
+	err := s.server.Listen()
+	if errors.Is(err, net.ErrClosed) {
+		return nil
+	}
+
+	return errors.WithStack(err)
+}
+
+func (s *Server) serveHTTP(server *http.Server) {
+	defer s.interval.Start("serveHTTP").End()
+
+	err := server.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		s.config.Logger.Err(errors.WithStack(err)).Stack().Send()
+	}
 }
 
 func (s *Server) Close() error {
 	defer s.interval.Start("Close").End()
 
+	for _, server := range []*http.Server{s.admin, s.metricsServer} {
+		if server == nil {
+			continue
+		}
+
+		err := server.Close()
+		if err != nil {
+			s.config.Logger.Err(errors.WithStack(err)).Stack().Send()
+		}
+	}
+
 	return errors.WithStack(s.server.Close())
 }
 
-func (s *Server) findFreeServer() *connWrapper {
-	defer s.interval.Start("findFreeServer").End()
+// reserveFreeServer finds server with the most free capacity and reserves one slot on it.
+// Excluded and recently failed servers are skipped. Returns nil if there is no free server.
+func (s *Server) reserveFreeServer(
+	ctx context.Context,
+	roomID uint64,
+	excluded map[*connWrapper]struct{},
+) *connWrapper {
+	_, interval := apm.StartContext(apm.ContextWithRoomID(ctx, roomID), s.interval, "reserveFreeServer")
+	defer interval.End()
 
-	var best *connWrapper
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+
+	for {
+		var (
+			best     *connWrapper
+			bestFree uint64
+		)
+
+		for id, ss := range s.clients {
+			if _, ok := s.drains[id]; ok {
+				continue
+			}
 
-	for _, ss := range s.clients {
-		if ss.stats.Capacity > 0 && (best == nil || ss.stats.Capacity > best.stats.Capacity) {
-			best = ss
+			if _, ok := excluded[ss]; ok || ss.inBackoff(now) {
+				continue
+			}
+
+			if free := ss.freeCapacity(); free > bestFree {
+				best = ss
+				bestFree = free
+			}
+		}
+
+		if best == nil {
+			return nil
 		}
-	}
 
-	return best
+		// concurrent request could take last slot.
+		if best.reserve(roomID) {
+			return best
+		}
+	}
 }
 
 func (s *Server) onStats() {
 	defer s.interval.Start("onStats").End()
 
-	s.condStats.Broadcast()
+	s.statsUpdated.Broadcast()
 }
 
-func (s *Server) waitStats() <-chan struct{} {
-	defer s.interval.Start("waitStats").End()
-
-	res := make(chan struct{}, 1)
-
-	utils.WithChannel(res).
-		BeforeClose(func() { res <- struct{}{} }).
-		AsyncCloseAfterFunc(s.condStats.Wait)
+// CreateRoom creates room on the most free session server. Safe for concurrent use.
+func (s *Server) CreateRoom(ctx context.Context, userIDs []uint64) (*proto.Room, error) {
+	ctx, interval := apm.StartContext(ctx, s.interval, "CreateRoom")
+	defer interval.End()
 
-	return res
+	return s.CreateRoomFromSpec(ctx, RoomSpec{
+		Clients: userIDs,
+	})
 }
 
-func (s *Server) CreateRoom(ctx context.Context, userIDs []uint64) (*proto.Room, error) {
-	defer s.interval.Start("CreateRoom").End()
-
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// CreateRoomFromSpec creates room with teams and parties on the most free session server. Safe for concurrent use.
+// Retryable failures are retried on other servers up to MaxAttempts.
+func (s *Server) CreateRoomFromSpec(ctx context.Context, spec RoomSpec) (*proto.Room, error) {
+	ctx, interval := apm.StartContext(ctx, s.interval, "CreateRoomFromSpec")
+	defer interval.End()
 
-	room := &proto.Room{
-		ID:      s.config.Storage.NewRoom(),
-		Clients: make([]*proto.Client, len(userIDs)),
-	}
+	place := s.startPlacement(ctx, spec)
+	defer s.observePlacement(place)
 
-	for index, id := range userIDs {
-		room.Clients[index] = &proto.Client{
-			ID: id,
-		}
-	}
+	ctx = apm.ContextWithRoomID(ctx, place.room.ID)
 
 	ctx, cancelFn := context.WithTimeout(ctx, s.config.CreateTimeout)
 	defer cancelFn()
 
-	done := ctx.Done()
-
 	for {
-		select {
-		case <-done:
-			return nil, ctx.Err()
-		default:
-		}
+		statsUpdated := s.statsUpdated.Wait()
 
-		best := s.findFreeServer()
+		best := s.reserveFreeServer(ctx, place.room.ID, place.excluded)
 
 		if best == nil {
 			select {
-			case <-done:
-				return nil, ctx.Err()
-			case <-s.waitStats():
+			case <-ctx.Done():
+				s.expirePlacement(place, ctx.Err())
+
+				return place.result.Room, place.result.Error
+			case <-statsUpdated:
 				continue
 			}
 		}
 
-		waiter := best.WaitRoomCreateResult(ctx, room.ID)
+		span := s.traceAttempt(place, best)
+		res := s.attemptCreate(ctx, best, place.room)
 
-		best.RoomCreate(room)
+		span.SetError(res.Error)
+		span.End()
 
-		res := <-waiter
-
-		if res.Error != nil {
-			best.RoomCancel(room.ID)
+		if s.applyCreateResult(ctx, best, place, res) {
+			return place.result.Room, place.result.Error
 		}
+	}
+}
 
-		if res.Room == nil {
-			continue
-		}
+// attemptCreate sends room to server and waits result for AttemptTimeout.
+func (s *Server) attemptCreate(ctx context.Context, server *connWrapper, room *proto.Room) RoomCreateResult {
+	ctx, interval := apm.StartContext(apm.ContextWithServerID(ctx, server.id), s.interval, "attemptCreate")
+	defer interval.End()
+
+	ctx, cancelFn := context.WithTimeout(ctx, s.config.AttemptTimeout)
+	defer cancelFn()
+
+	waiter := server.WaitRoomCreateResult(ctx, room.ID)
+
+	server.RoomCreate(room)
+
+	return <-waiter
+}
+
+// newRoom creates room with new id from spec.
+func (s *Server) newRoom(spec RoomSpec) *proto.Room {
+	room := &proto.Room{
+		ID:      s.config.Storage.NewRoom(),
+		Clients: spec.clients(),
+	}
+
+	s.saveRoomEvent(&storage.RoomEvent{
+		Status:  storage.RoomStatusRequested,
+		RoomID:  room.ID,
+		Clients: spec.clientIDs(),
+	})
+
+	return room
+}
+
+func (s *Server) saveRoomError(roomID uint64, serverID uint64, err error) {
+	s.saveRoomEvent(&storage.RoomEvent{
+		Status:   storage.RoomStatusError,
+		RoomID:   roomID,
+		ServerID: serverID,
+		Error:    err.Error(),
+	})
+}
+
+func (s *Server) saveRoomEvent(event *storage.RoomEvent) {
+	defer s.interval.Start("saveRoomEvent").End()
+
+	if s.config.RoomStore == nil {
+		return
+	}
 
-		res.Room.ServerID = best.id
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
 
-		return res.Room, res.Error
+	err := errors.WithStack(s.config.RoomStore.Append(event))
+	if err != nil {
+		s.config.Logger.Err(err).Stack().Send()
 	}
 }
 
@@ -254,6 +504,8 @@ func (s *Server) removeFinishedListener(listener chan *proto.Room) {
 }
 
 // FinishedRooms creates channel-receiver of all finished rooms. To close channel cancel context ctx.
+// Rooms finished while no receiver attached or receiver buffer is full are lost,
+// see ConsumeFinishedRooms for durable delivery.
 func (s *Server) FinishedRooms(ctx context.Context) <-chan *proto.Room {
 	defer s.interval.Start("FinishedRooms").End()
 
@@ -274,5 +526,5 @@ func (s *Server) notifyFinishedRoom(room *proto.Room) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	utils.WithChannels(s.listenersFinished).Notify(room)
+	utils.WithChannels(s.listenersFinished).TryNotify(room)
 }
@@ -0,0 +1,144 @@
+package master
+
+import (
+	"time"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/opoccomaxao-go/rooms/storage"
+	"github.com/pkg/errors"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// ServerInfo is state of connected session server.
+type ServerInfo struct {
+	ID       uint64            `json:"id"`
+	Address  string            `json:"address,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Stats    proto.Stats       `json:"stats"`
+	Reserved uint64            `json:"reserved"` // Reserved is count of slots taken by rooms in creation.
+	LastSeen time.Time         `json:"last_seen"`
+	Lost     bool              `json:"lost"`     // Lost is true for disconnected server waiting for reconnect.
+	Draining bool              `json:"draining"` // Draining server doesn't get new rooms.
+}
+
+// Servers returns all session servers ordered by id.
+func (s *Server) Servers() []ServerInfo {
+	defer s.interval.Start("Servers").End()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := maps.Keys(s.clients)
+	slices.Sort(ids)
+
+	res := make([]ServerInfo, len(ids))
+
+	for i, id := range ids {
+		res[i] = s.serverInfo(s.clients[id])
+	}
+
+	return res
+}
+
+// ServerByID returns session server or constants.ErrNotFound.
+func (s *Server) ServerByID(serverID uint64) (ServerInfo, error) {
+	defer s.interval.Start("ServerByID").End()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	conn, ok := s.clients[serverID]
+	if !ok {
+		return ServerInfo{}, errors.Wrapf(constants.ErrNotFound, "server %d", serverID)
+	}
+
+	return s.serverInfo(conn), nil
+}
+
+// serverInfo requires s.mu.
+func (s *Server) serverInfo(conn *connWrapper) ServerInfo {
+	_, draining := s.drains[conn.id]
+
+	conn.mu.RLock()
+	defer conn.mu.RUnlock()
+
+	return ServerInfo{
+		ID:       conn.id,
+		Address:  conn.address,
+		Labels:   conn.labels,
+		Stats:    conn.stats,
+		Reserved: conn.ledger.count(),
+		LastSeen: conn.LastSeen(),
+		Lost:     conn.isLost(),
+		Draining: draining,
+	}
+}
+
+// Drain excludes session server from scheduling. Running rooms aren't affected.
+// EventServerDrained is published when server has no rooms.
+func (s *Server) Drain(serverID uint64) error {
+	defer s.interval.Start("Drain").End()
+
+	return s.setDraining(serverID, true)
+}
+
+// Undrain returns session server to scheduling.
+func (s *Server) Undrain(serverID uint64) error {
+	defer s.interval.Start("Undrain").End()
+
+	return s.setDraining(serverID, false)
+}
+
+func (s *Server) setDraining(serverID uint64, draining bool) error {
+	s.mu.Lock()
+
+	conn, ok := s.clients[serverID]
+	if !ok {
+		s.mu.Unlock()
+
+		return errors.Wrapf(constants.ErrNotFound, "server %d", serverID)
+	}
+
+	if draining {
+		s.drains[serverID] = struct{}{}
+	} else {
+		delete(s.drains, serverID)
+	}
+
+	s.mu.Unlock()
+
+	s.checkDrained(conn)
+
+	if !draining {
+		s.statsUpdated.Broadcast()
+	}
+
+	return nil
+}
+
+func (s *Server) isDraining(serverID uint64) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.drains[serverID]
+
+	return ok
+}
+
+// RoomHistory returns record of room from RoomStore or constants.ErrNotFound.
+func (s *Server) RoomHistory(roomID uint64) (*storage.RoomRecord, error) {
+	defer s.interval.Start("RoomHistory").End()
+
+	if s.config.RoomStore == nil {
+		return nil, errors.Wrap(constants.ErrNotFound, "room store")
+	}
+
+	res, err := s.config.RoomStore.Room(roomID)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,49 @@
+package master
+
+import "github.com/opoccomaxao-go/rooms/proto"
+
+// RoomSpec describes requested room.
+type RoomSpec struct {
+	Clients []uint64    `json:"clients,omitempty"` // Clients are solo players without team.
+	Parties []PartySpec `json:"parties,omitempty"` // optional. Parties are groups of players kept together.
+}
+
+// PartySpec is group of players which should be in the same room and team.
+type PartySpec struct {
+	Team    uint32   `json:"team,omitempty"` // optional. Team number, zero means no team.
+	Clients []uint64 `json:"clients"`        // Clients are party members.
+}
+
+// clients returns all clients of room with their teams and parties. Party ids are unique within room.
+func (s RoomSpec) clients() []*proto.Client {
+	res := make([]*proto.Client, 0, len(s.Clients))
+
+	for _, id := range s.Clients {
+		res = append(res, &proto.Client{
+			ID: id,
+		})
+	}
+
+	for index, party := range s.Parties {
+		for _, id := range party.Clients {
+			res = append(res, &proto.Client{
+				ID:    id,
+				Team:  party.Team,
+				Party: uint64(index + 1),
+			})
+		}
+	}
+
+	return res
+}
+
+// clientIDs returns ids of all clients of room.
+func (s RoomSpec) clientIDs() []uint64 {
+	res := append([]uint64(nil), s.Clients...)
+
+	for _, party := range s.Parties {
+		res = append(res, party.Clients...)
+	}
+
+	return res
+}
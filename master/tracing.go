@@ -0,0 +1,40 @@
+package master
+
+import (
+	"context"
+
+	"github.com/opoccomaxao-go/rooms/apm"
+	"github.com/opoccomaxao-go/rooms/proto"
+)
+
+// startPlacement creates room from spec with root span of its creation.
+func (s *Server) startPlacement(ctx context.Context, spec RoomSpec) *placement {
+	res := newPlacement(s.newRoom(spec))
+
+	res.traceCtx, res.span = s.config.Tracer.Start(ctx, "master.CreateRoom")
+	res.span.SetAttribute("room.id", res.room.ID)
+
+	return res
+}
+
+// traceAttempt starts span of creation attempt on server. Span is propagated to session server with room.
+func (s *Server) traceAttempt(p *placement, server *connWrapper) apm.Span {
+	_, span := s.config.Tracer.Start(p.traceCtx, "master.RoomCreate")
+	span.SetAttribute("room.id", p.room.ID)
+	span.SetAttribute("server.id", server.id)
+
+	p.room.Trace = span.Context().TraceParent()
+
+	return span
+}
+
+// traceReply records reply of session server as child of session span.
+func (s *Server) traceReply(name string, serverID uint64, room *proto.Room) {
+	ctx := apm.ContextWithTraceParent(context.Background(), room.Trace)
+
+	_, span := s.config.Tracer.Start(ctx, name)
+	span.SetAttribute("room.id", room.ID)
+	span.SetAttribute("server.id", serverID)
+	span.SetError(room.Error)
+	span.End()
+}
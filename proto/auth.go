@@ -1,22 +1,35 @@
 package proto
 
 import (
-	"encoding/json"
-
+	"github.com/opoccomaxao-go/rooms/constants"
 	"github.com/pkg/errors"
 )
 
 type Auth struct {
-	Version string `json:"version"`
-	Token   string `json:"token"`
+	Version string            `json:"version"`
+	Token   string            `json:"token"`
+	Address string            `json:"address,omitempty"` // Address is advertised endpoint of session server.
+	Labels  map[string]string `json:"labels,omitempty"`
+	Codec   string            `json:"codec,omitempty"` // Codec is name of requested payload codec, JSON if empty.
 }
 
-func (a *Auth) Payload() []byte {
-	res, _ := json.Marshal(a)
-
-	return res
+func (a *Auth) Payload() ([]byte, error) {
+	return JSON.Marshal(a)
 }
 
+// Read decodes JSON payload and validates it.
 func (a *Auth) Read(data []byte) error {
-	return errors.WithStack(json.Unmarshal(data, a))
+	return JSON.Unmarshal(data, a)
+}
+
+func (a *Auth) Validate() error {
+	if a.Version == "" {
+		return errors.WithMessage(constants.ErrMalformed, "empty version")
+	}
+
+	if a.Token == "" {
+		return errors.WithMessage(constants.ErrMalformed, "empty token")
+	}
+
+	return nil
 }
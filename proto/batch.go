@@ -0,0 +1,78 @@
+package proto
+
+import (
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/pkg/errors"
+)
+
+// batchOverhead is reserved for RoomBatch fields except rooms.
+const batchOverhead = 16
+
+// RoomBatch is list of rooms to create.
+type RoomBatch struct {
+	Rooms []*Room `json:"rooms"`
+}
+
+func (b *RoomBatch) Payload() ([]byte, error) {
+	return JSON.Marshal(b)
+}
+
+// Read decodes JSON payload and validates it.
+func (b *RoomBatch) Read(data []byte) error {
+	return JSON.Unmarshal(data, b)
+}
+
+// Validate checks that batch isn't empty and contains valid rooms with unique ids.
+func (b *RoomBatch) Validate() error {
+	if len(b.Rooms) == 0 {
+		return errors.WithMessage(constants.ErrMalformed, "empty batch")
+	}
+
+	return validateRooms(b.Rooms)
+}
+
+// SplitRoomBatch splits rooms into batches with payload not larger than maxSize.
+func SplitRoomBatch(rooms []*Room, maxSize int) ([]*RoomBatch, error) {
+	pages, err := SplitRooms(rooms, maxSize-batchOverhead)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*RoomBatch, len(pages))
+
+	for i, page := range pages {
+		res[i] = &RoomBatch{
+			Rooms: page,
+		}
+	}
+
+	return res, nil
+}
+
+// SplitRooms splits rooms into pages with serialized size not larger than maxSize.
+// Room larger than maxSize takes whole page. Always returns at least one page.
+func SplitRooms(rooms []*Room, maxSize int) ([][]*Room, error) {
+	res := [][]*Room{{}}
+	size := 0
+
+	for _, room := range rooms {
+		payload, err := room.Payload()
+		if err != nil {
+			return nil, errors.WithMessagef(err, "room %d", room.ID)
+		}
+
+		roomSize := len(payload) + 1
+		last := len(res) - 1
+
+		if len(res[last]) > 0 && size+roomSize > maxSize {
+			res = append(res, nil)
+			last++
+			size = 0
+		}
+
+		res[last] = append(res[last], room)
+		size += roomSize
+	}
+
+	return res, nil
+}
@@ -0,0 +1,316 @@
+package proto
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/pkg/errors"
+)
+
+// binaryWriter appends values: unsigned integers as uvarint, bytes and strings with uvarint length prefix.
+type binaryWriter struct {
+	buf []byte
+}
+
+func (w *binaryWriter) uvarint(value uint64) {
+	var buf [binary.MaxVarintLen64]byte
+
+	n := binary.PutUvarint(buf[:], value)
+	w.buf = append(w.buf, buf[:n]...)
+}
+
+func (w *binaryWriter) bool(value bool) {
+	if value {
+		w.buf = append(w.buf, 1)
+	} else {
+		w.buf = append(w.buf, 0)
+	}
+}
+
+func (w *binaryWriter) bytes(value []byte) {
+	w.uvarint(uint64(len(value)))
+	w.buf = append(w.buf, value...)
+}
+
+func (w *binaryWriter) string(value string) {
+	w.uvarint(uint64(len(value)))
+	w.buf = append(w.buf, value...)
+}
+
+// stringMap writes count and pairs sorted by key.
+func (w *binaryWriter) stringMap(value map[string]string) {
+	keys := make([]string, 0, len(value))
+	for key := range value {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	w.uvarint(uint64(len(keys)))
+
+	for _, key := range keys {
+		w.string(key)
+		w.string(value[key])
+	}
+}
+
+// rooms writes count and rooms, nil room is written as empty one.
+func (w *binaryWriter) rooms(rooms []*Room) {
+	w.uvarint(uint64(len(rooms)))
+
+	for _, room := range rooms {
+		if room == nil {
+			room = &Room{}
+		}
+
+		room.writeBinary(w)
+	}
+}
+
+// binaryReader reads values written by binaryWriter. The first error stops reading, zero values are returned then.
+type binaryReader struct {
+	data []byte
+	err  error
+}
+
+func (r *binaryReader) fail(reason string) {
+	if r.err == nil {
+		r.err = errors.WithMessage(constants.ErrMalformed, "binary: "+reason)
+	}
+
+	r.data = nil
+}
+
+func (r *binaryReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+
+	res, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.fail("bad varint")
+
+		return 0
+	}
+
+	r.data = r.data[n:]
+
+	return res
+}
+
+// uvarintMax reads uvarint not larger than limit.
+func (r *binaryReader) uvarintMax(limit uint64) uint64 {
+	res := r.uvarint()
+	if res > limit {
+		r.fail("value out of range")
+
+		return 0
+	}
+
+	return res
+}
+
+// count reads count of items. Every item takes at least one byte, so count is limited by remaining data.
+func (r *binaryReader) count() int {
+	return r.countMin(1)
+}
+
+// countMin reads count of items, each takes at least itemSize bytes.
+func (r *binaryReader) countMin(itemSize int) int {
+	res := r.uvarint()
+	if res > uint64(len(r.data)/itemSize) {
+		r.fail("count out of range")
+
+		return 0
+	}
+
+	return int(res)
+}
+
+func (r *binaryReader) bool() bool {
+	switch r.uvarintMax(1) {
+	case 1:
+		return true
+	default:
+		return false
+	}
+}
+
+// bytes returns copy of data or nil if empty.
+func (r *binaryReader) bytes() []byte {
+	size := r.count()
+	if size == 0 {
+		return nil
+	}
+
+	res := make([]byte, size)
+	copy(res, r.data)
+	r.data = r.data[size:]
+
+	return res
+}
+
+func (r *binaryReader) string() string {
+	size := r.count()
+	res := string(r.data[:size])
+	r.data = r.data[size:]
+
+	return res
+}
+
+// stringMap returns nil if map is empty.
+func (r *binaryReader) stringMap() map[string]string {
+	// every pair takes at least 2 bytes.
+	size := r.countMin(2)
+	if size == 0 {
+		return nil
+	}
+
+	res := make(map[string]string, size)
+
+	for i := 0; i < size && r.err == nil; i++ {
+		key := r.string()
+		res[key] = r.string()
+	}
+
+	return res
+}
+
+// rooms returns nil if there are no rooms.
+func (r *binaryReader) rooms() []*Room {
+	size := r.count()
+	if size == 0 {
+		return nil
+	}
+
+	res := make([]*Room, 0, size)
+
+	for i := 0; i < size && r.err == nil; i++ {
+		var room Room
+
+		room.readBinary(r)
+
+		res = append(res, &room)
+	}
+
+	return res
+}
+
+func (a *Auth) writeBinary(w *binaryWriter) {
+	w.string(a.Version)
+	w.string(a.Token)
+	w.string(a.Address)
+	w.stringMap(a.Labels)
+	w.string(a.Codec)
+}
+
+func (a *Auth) readBinary(r *binaryReader) {
+	a.Version = r.string()
+	a.Token = r.string()
+	a.Address = r.string()
+	a.Labels = r.stringMap()
+	a.Codec = r.string()
+}
+
+func (c *Client) writeBinary(w *binaryWriter) {
+	w.uvarint(c.ID)
+	w.bytes(c.Token)
+	w.uvarint(uint64(c.Team))
+	w.uvarint(c.Party)
+}
+
+func (c *Client) readBinary(r *binaryReader) {
+	c.ID = r.uvarint()
+	c.Token = r.bytes()
+	c.Team = uint32(r.uvarintMax(math.MaxUint32))
+	c.Party = r.uvarint()
+}
+
+func (e *Error) writeBinary(w *binaryWriter) {
+	w.uvarint(uint64(e.Code))
+	w.string(e.Message)
+	w.stringMap(e.Details)
+}
+
+func (e *Error) readBinary(r *binaryReader) {
+	e.Code = ErrorCode(r.uvarintMax(math.MaxUint16))
+	e.Message = r.string()
+	e.Details = r.stringMap()
+}
+
+func (i *Inventory) writeBinary(w *binaryWriter) {
+	w.rooms(i.Rooms)
+	w.bool(i.More)
+}
+
+func (i *Inventory) readBinary(r *binaryReader) {
+	i.Rooms = r.rooms()
+	i.More = r.bool()
+}
+
+// writeBinary writes room except ServerID, it isn't sent as in JSON. Nil client is written as empty one.
+func (r *Room) writeBinary(w *binaryWriter) {
+	w.uvarint(r.ID)
+	w.uvarint(uint64(len(r.Clients)))
+
+	for _, client := range r.Clients {
+		if client == nil {
+			client = &Client{}
+		}
+
+		client.writeBinary(w)
+	}
+
+	w.string(r.Endpoint)
+	w.bytes(r.Result)
+	w.bool(r.Error != nil)
+
+	if r.Error != nil {
+		r.Error.writeBinary(w)
+	}
+
+	w.string(r.Trace)
+}
+
+func (r *Room) readBinary(reader *binaryReader) {
+	r.ID = reader.uvarint()
+	r.Clients = nil
+
+	// every client takes at least 4 bytes.
+	for i, size := 0, reader.countMin(4); i < size && reader.err == nil; i++ {
+		var client Client
+
+		client.readBinary(reader)
+
+		r.Clients = append(r.Clients, &client)
+	}
+
+	r.Endpoint = reader.string()
+	r.Result = reader.bytes()
+	r.Error = nil
+
+	if reader.bool() {
+		r.Error = &Error{}
+		r.Error.readBinary(reader)
+	}
+
+	r.Trace = reader.string()
+}
+
+func (b *RoomBatch) writeBinary(w *binaryWriter) {
+	w.rooms(b.Rooms)
+}
+
+func (b *RoomBatch) readBinary(r *binaryReader) {
+	b.Rooms = r.rooms()
+}
+
+func (s *Stats) writeBinary(w *binaryWriter) {
+	w.uvarint(s.Capacity)
+}
+
+func (s *Stats) readBinary(r *binaryReader) {
+	s.Capacity = r.uvarint()
+}
@@ -1,15 +1,26 @@
 package proto
 
-import "encoding/json"
+import (
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/pkg/errors"
+)
 
 // Client any active entity.
 type Client struct {
 	ID    uint64 `json:"id"`
 	Token []byte `json:"token,omitempty"`
+	Team  uint32 `json:"team,omitempty"`  // Team number, zero means no team.
+	Party uint64 `json:"party,omitempty"` // Party id unique within room, zero means solo player.
 }
 
-func (c *Client) Payload() []byte {
-	res, _ := json.Marshal(c)
+func (c *Client) Payload() ([]byte, error) {
+	return JSON.Marshal(c)
+}
+
+func (c *Client) Validate() error {
+	if c.ID == 0 {
+		return errors.WithMessage(constants.ErrMalformed, "client id is zero")
+	}
 
-	return res
+	return nil
 }
@@ -0,0 +1,161 @@
+package proto
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/pkg/errors"
+)
+
+const (
+	CodecNameJSON   = "json"
+	CodecNameBinary = "binary"
+)
+
+// Message is payload of command, could be encoded with any Codec. Decoded messages are validated.
+type Message interface {
+	Validate() error
+	writeBinary(w *binaryWriter)
+	readBinary(r *binaryReader)
+}
+
+var (
+	_ Message = (*Auth)(nil)
+	_ Message = (*Client)(nil)
+	_ Message = (*Error)(nil)
+	_ Message = (*Inventory)(nil)
+	_ Message = (*Malformed)(nil)
+	_ Message = (*Room)(nil)
+	_ Message = (*RoomBatch)(nil)
+	_ Message = (*Stats)(nil)
+)
+
+// Codec encodes payloads of commands. Codec is selected per connection on Auth.
+type Codec interface {
+	Name() string
+	Marshal(msg Message) ([]byte, error)
+	Unmarshal(data []byte, msg Message) error
+}
+
+//nolint:gochecknoglobals // stateless codecs.
+var (
+	// JSON codec is readable, intended for debugging. Used before Auth and if session server requests no codec.
+	JSON Codec = codecJSON{}
+	// Binary codec is compact varint-based encoding.
+	Binary Codec = codecBinary{}
+)
+
+// CodecByName returns codec by name, empty name means JSON.
+func CodecByName(name string) (Codec, error) {
+	switch name {
+	case "", CodecNameJSON:
+		return JSON, nil
+	case CodecNameBinary:
+		return Binary, nil
+	default:
+		return nil, errors.Wrapf(constants.ErrInvalid, "unknown codec %q", name)
+	}
+}
+
+type codecJSON struct{}
+
+func (codecJSON) Name() string {
+	return CodecNameJSON
+}
+
+func (codecJSON) Marshal(msg Message) ([]byte, error) {
+	res, err := json.Marshal(msg)
+	if err != nil {
+		return nil, errors.Wrap(constants.ErrInvalid, err.Error())
+	}
+
+	return res, nil
+}
+
+func (codecJSON) Unmarshal(data []byte, msg Message) error {
+	err := json.Unmarshal(data, msg)
+	if err != nil {
+		return errors.WithMessage(constants.ErrMalformed, err.Error())
+	}
+
+	return errors.WithStack(msg.Validate())
+}
+
+type codecBinary struct{}
+
+func (codecBinary) Name() string {
+	return CodecNameBinary
+}
+
+func (codecBinary) Marshal(msg Message) ([]byte, error) {
+	var writer binaryWriter
+
+	msg.writeBinary(&writer)
+
+	return writer.buf, nil
+}
+
+func (codecBinary) Unmarshal(data []byte, msg Message) error {
+	reader := binaryReader{
+		data: data,
+	}
+
+	msg.readBinary(&reader)
+
+	if reader.err == nil && len(reader.data) > 0 {
+		reader.fail("trailing bytes")
+	}
+
+	if reader.err != nil {
+		return reader.err
+	}
+
+	return errors.WithStack(msg.Validate())
+}
+
+// NewEncoder encodes msg with current codec and returns function which returns payload in current codec on every call,
+// e.g. for resend after codec switch. Payload is decoded and encoded again only after codec change,
+// so later changes of msg don't affect payload.
+func NewEncoder(msg Message, current func() Codec) (func() ([]byte, error), error) {
+	codec := current()
+
+	payload, err := codec.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	msgType := reflect.TypeOf(msg).Elem()
+
+	var mu sync.Mutex
+
+	return func() ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		next := current()
+		if next == codec {
+			return payload, nil
+		}
+
+		decoded, ok := reflect.New(msgType).Interface().(Message)
+		if !ok {
+			return nil, errors.Wrapf(constants.ErrInvalid, "message %s", msgType)
+		}
+
+		err := codec.Unmarshal(payload, decoded)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := next.Marshal(decoded)
+		if err != nil {
+			return nil, err
+		}
+
+		codec, payload = next, res
+
+		return payload, nil
+	}, nil
+}
@@ -0,0 +1,224 @@
+package proto
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRoom() *Room {
+	return &Room{
+		ID: 1 << 40,
+		Clients: []*Client{
+			{ID: 1, Token: []byte("token"), Team: 1, Party: 3},
+			{ID: 2, Team: 2},
+		},
+		Endpoint: "127.0.0.1:9000",
+		Result:   json.RawMessage(`{"winner":1}`),
+		Error: &Error{
+			Code:    ErrorCodeCapacity,
+			Message: "full",
+			Details: map[string]string{"capacity": "1"},
+		},
+		Trace: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	}
+}
+
+// testMessages returns sample of every message and constructor of empty one.
+func testMessages() []struct {
+	name  string
+	msg   Message
+	empty func() Message
+} {
+	return []struct {
+		name  string
+		msg   Message
+		empty func() Message
+	}{
+		{
+			name: "Auth",
+			msg: &Auth{
+				Version: "3",
+				Token:   "token",
+				Address: "127.0.0.1:9000",
+				Labels:  map[string]string{"region": "eu", "zone": "a"},
+				Codec:   CodecNameBinary,
+			},
+			empty: func() Message { return &Auth{} },
+		},
+		{
+			name:  "Client",
+			msg:   &Client{ID: 1, Token: []byte{0, 1, 2}, Team: 4, Party: 5},
+			empty: func() Message { return &Client{} },
+		},
+		{
+			name:  "Error",
+			msg:   &Error{Code: ErrorCodeBadToken, Message: "bad token"},
+			empty: func() Message { return &Error{} },
+		},
+		{
+			name:  "Inventory",
+			msg:   &Inventory{Rooms: []*Room{testRoom(), {ID: 2}}, More: true},
+			empty: func() Message { return &Inventory{} },
+		},
+		{
+			name:  "Malformed",
+			msg:   &Malformed{Command: CommandSessionStats, Error: &Error{Code: ErrorCodeInvalid, Message: "bad"}},
+			empty: func() Message { return &Malformed{} },
+		},
+		{
+			name:  "Room",
+			msg:   testRoom(),
+			empty: func() Message { return &Room{} },
+		},
+		{
+			name:  "RoomBatch",
+			msg:   &RoomBatch{Rooms: []*Room{testRoom(), {ID: 3}}},
+			empty: func() Message { return &RoomBatch{} },
+		},
+		{
+			name:  "Stats",
+			msg:   &Stats{Capacity: 300},
+			empty: func() Message { return &Stats{} },
+		},
+	}
+}
+
+func TestCodec_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, codec := range []Codec{JSON, Binary} {
+		for _, test := range testMessages() {
+			data, err := codec.Marshal(test.msg)
+			require.NoError(t, err)
+
+			res := test.empty()
+
+			require.NoError(t, codec.Unmarshal(data, res), "%s %s", codec.Name(), test.name)
+			assert.Equal(t, test.msg, res, "%s %s", codec.Name(), test.name)
+		}
+	}
+}
+
+func TestCodecBinary_Size(t *testing.T) {
+	t.Parallel()
+
+	room := &Room{ID: 1}
+
+	for i := uint64(1); i <= 500; i++ {
+		room.Clients = append(room.Clients, &Client{ID: i, Team: uint32(i % 2), Party: i})
+	}
+
+	jsonData, err := JSON.Marshal(room)
+	require.NoError(t, err)
+
+	binaryData, err := Binary.Marshal(room)
+	require.NoError(t, err)
+
+	assert.Less(t, len(binaryData)*4, len(jsonData))
+}
+
+func TestCodecBinary_Invalid(t *testing.T) {
+	t.Parallel()
+
+	data, err := Binary.Marshal(testRoom())
+	require.NoError(t, err)
+
+	for i := 0; i < len(data); i++ {
+		assert.Error(t, Binary.Unmarshal(data[:i], &Room{}), i)
+	}
+
+	assert.Error(t, Binary.Unmarshal(append(data, 0), &Room{}))
+	assert.Error(t, Binary.Unmarshal([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, &Stats{}))
+}
+
+func TestNewEncoder(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range testMessages() {
+		codec := JSON
+
+		encode, err := NewEncoder(test.msg, func() Codec { return codec })
+		require.NoError(t, err)
+
+		for _, next := range []Codec{JSON, Binary, JSON} {
+			codec = next
+
+			data, err := encode()
+			require.NoError(t, err, "%s %s", next.Name(), test.name)
+
+			res := test.empty()
+
+			require.NoError(t, next.Unmarshal(data, res), "%s %s", next.Name(), test.name)
+			assert.Equal(t, test.msg, res, "%s %s", next.Name(), test.name)
+		}
+	}
+}
+
+func TestCodecByName(t *testing.T) {
+	t.Parallel()
+
+	for name, expected := range map[string]Codec{
+		"":              JSON,
+		CodecNameJSON:   JSON,
+		CodecNameBinary: Binary,
+	} {
+		codec, err := CodecByName(name)
+		require.NoError(t, err)
+		assert.Equal(t, expected, codec)
+	}
+
+	_, err := CodecByName("xml")
+	assert.Error(t, err)
+}
+
+func FuzzCodecBinary(f *testing.F) {
+	for i, test := range testMessages() {
+		data, err := Binary.Marshal(test.msg)
+		require.NoError(f, err)
+
+		f.Add(uint8(i), data)
+	}
+
+	messages := testMessages()
+
+	f.Fuzz(func(t *testing.T, kind uint8, data []byte) {
+		test := messages[int(kind)%len(messages)]
+
+		msg := test.empty()
+		if Binary.Unmarshal(data, msg) != nil {
+			return
+		}
+
+		encoded, err := Binary.Marshal(msg)
+		require.NoError(t, err)
+
+		res := test.empty()
+
+		require.NoError(t, Binary.Unmarshal(encoded, res))
+		assert.Equal(t, msg, res)
+	})
+}
+
+func FuzzCodecJSON(f *testing.F) {
+	for i, test := range testMessages() {
+		data, err := JSON.Marshal(test.msg)
+		require.NoError(f, err)
+
+		f.Add(uint8(i), data)
+	}
+
+	messages := testMessages()
+
+	f.Fuzz(func(t *testing.T, kind uint8, data []byte) {
+		msg := messages[int(kind)%len(messages)].empty()
+		if JSON.Unmarshal(data, msg) != nil {
+			return
+		}
+
+		_, err := JSON.Marshal(msg)
+		require.NoError(t, err)
+	})
+}
@@ -1,10 +1,21 @@
 package proto
 
+import (
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/pkg/errors"
+)
+
 const (
 	CommandMasterAuthRequired uint16 = iota + 1
 	CommandMasterAuthSuccess
 	CommandMasterRoomCreate
 	CommandMasterRoomCancel
+	CommandMasterRoomAck
+	CommandMasterAck
+	CommandMasterNack
+	CommandMasterRoomCreateBatch
+	CommandMasterHeartbeat
+	CommandMasterMalformed
 )
 
 const (
@@ -13,4 +24,28 @@ const (
 	CommandSessionRoomError
 	CommandSessionRoomFinished
 	CommandSessionStats
+	CommandSessionAck
+	CommandSessionNack
+	CommandSessionInventory
+	CommandSessionHeartbeat
+	CommandSessionMalformed
+)
+
+// Custom commands are reserved for application in both directions, protocol never uses them.
+const (
+	CommandCustomMin uint16 = 0x8000
+	CommandCustomMax uint16 = 0xFFFF
 )
+
+// ValidateCustomCommand checks that command is in custom range and payload fits into command.
+func ValidateCustomCommand(command uint16, payload []byte) error {
+	if command < CommandCustomMin {
+		return errors.Wrapf(constants.ErrInvalid, "command %d isn't custom", command)
+	}
+
+	if len(payload) > MaxPayloadSize {
+		return errors.Wrapf(constants.ErrOverflow, "command %d payload size %d", command, len(payload))
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package proto
+
+import (
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/pkg/errors"
+)
+
+// ErrorCode classifies errors sent with RoomError and AuthRequired.
+type ErrorCode uint16
+
+const (
+	ErrorCodeUnknown         ErrorCode = iota // ErrorCodeUnknown is unclassified error, fatal.
+	ErrorCodeCapacity                         // ErrorCodeCapacity means session server has no free slots, retryable.
+	ErrorCodeInvalid                          // ErrorCodeInvalid means invalid request, fatal.
+	ErrorCodeEngineInit                       // ErrorCodeEngineInit means engine failed to init room, fatal.
+	ErrorCodeVersionMismatch                  // ErrorCodeVersionMismatch means unsupported protocol version, fatal.
+	ErrorCodeBadToken                         // ErrorCodeBadToken means unknown auth token, fatal.
+
+	errorCodeMax = ErrorCodeBadToken
+)
+
+// codeErrors maps codes to sentinel errors, specific errors go first.
+var codeErrors = []struct {
+	code ErrorCode
+	err  error
+}{
+	{ErrorCodeVersionMismatch, constants.ErrVersionMismatch},
+	{ErrorCodeBadToken, constants.ErrBadToken},
+	{ErrorCodeCapacity, constants.ErrCapacity},
+	{ErrorCodeEngineInit, constants.ErrEngineInit},
+	{ErrorCodeInvalid, constants.ErrInvalid},
+}
+
+// Retryable returns true if room could be created on another session server.
+func (c ErrorCode) Retryable() bool {
+	return c == ErrorCodeCapacity
+}
+
+// Err returns sentinel error of code from constants. Returns nil for unknown code.
+func (c ErrorCode) Err() error {
+	for _, item := range codeErrors {
+		if item.code == c {
+			return item.err
+		}
+	}
+
+	return nil
+}
+
+// ErrorCodeOf returns code of err by its sentinel error.
+func ErrorCodeOf(err error) ErrorCode {
+	var protoErr *Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code
+	}
+
+	for _, item := range codeErrors {
+		if errors.Is(err, item.err) {
+			return item.code
+		}
+	}
+
+	return ErrorCodeUnknown
+}
+
+// Error is error sent between master and session server.
+// Sentinel error of code is available with errors.Is.
+type Error struct {
+	Code    ErrorCode         `json:"code"`
+	Message string            `json:"message"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// NewError converts err into Error with code of its sentinel error.
+func NewError(err error) *Error {
+	return &Error{
+		Code:    ErrorCodeOf(err),
+		Message: err.Error(),
+	}
+}
+
+// Error returns message. Safe for nil.
+func (e *Error) Error() string {
+	if e == nil {
+		return ""
+	}
+
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Code.Err()
+}
+
+func (e *Error) Retryable() bool {
+	return e.Code.Retryable()
+}
+
+func (e *Error) Payload() ([]byte, error) {
+	return JSON.Marshal(e)
+}
+
+// Read decodes JSON payload and validates it.
+func (e *Error) Read(data []byte) error {
+	return JSON.Unmarshal(data, e)
+}
+
+// Validate checks that code is known.
+func (e *Error) Validate() error {
+	if e.Code > errorCodeMax {
+		return errors.WithMessagef(constants.ErrMalformed, "unknown error code %d", e.Code)
+	}
+
+	return nil
+}
@@ -2,6 +2,9 @@ package proto
 
 import (
 	"encoding/binary"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/pkg/errors"
 )
 
 const uint64Bytes = 8
@@ -16,6 +19,16 @@ func PayloadID(id ID) []byte {
 	return res
 }
 
-func ReadID(payload []byte) ID {
-	return binary.BigEndian.Uint64(payload)
+// ReadID reads non-zero id from payload of exactly 8 bytes.
+func ReadID(payload []byte) (ID, error) {
+	if len(payload) != uint64Bytes {
+		return 0, errors.WithMessagef(constants.ErrMalformed, "id size %d", len(payload))
+	}
+
+	res := binary.BigEndian.Uint64(payload)
+	if res == 0 {
+		return 0, errors.WithMessage(constants.ErrMalformed, "id is zero")
+	}
+
+	return res, nil
 }
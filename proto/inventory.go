@@ -0,0 +1,49 @@
+package proto
+
+import "math"
+
+// MaxPayloadSize is limited by event header.
+const MaxPayloadSize = math.MaxUint16
+
+// inventoryOverhead is reserved for Inventory fields except rooms.
+const inventoryOverhead = 32
+
+// Inventory is list of running rooms on session server. Could be split into several pages.
+type Inventory struct {
+	Rooms []*Room `json:"rooms"`
+	More  bool    `json:"more,omitempty"` // More is true for all pages except the last one.
+}
+
+func (i *Inventory) Payload() ([]byte, error) {
+	return JSON.Marshal(i)
+}
+
+// Read decodes JSON payload and validates it.
+func (i *Inventory) Read(data []byte) error {
+	return JSON.Unmarshal(data, i)
+}
+
+// Validate checks that rooms are valid and have unique ids.
+func (i *Inventory) Validate() error {
+	return validateRooms(i.Rooms)
+}
+
+// SplitInventory splits rooms into pages with payload not larger than maxSize.
+// Always returns at least one page.
+func SplitInventory(rooms []*Room, maxSize int) ([]*Inventory, error) {
+	pages, err := SplitRooms(rooms, maxSize-inventoryOverhead)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*Inventory, len(pages))
+
+	for i, page := range pages {
+		res[i] = &Inventory{
+			Rooms: page,
+			More:  i < len(pages)-1,
+		}
+	}
+
+	return res, nil
+}
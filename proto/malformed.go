@@ -0,0 +1,55 @@
+package proto
+
+import (
+	"math"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/pkg/errors"
+)
+
+// Malformed reports command rejected because of invalid payload. It is always encoded with JSON codec.
+type Malformed struct {
+	Command uint16 `json:"command"` // Command is type of rejected command.
+	Error   *Error `json:"error"`
+}
+
+// NewMalformed creates report of rejected command.
+func NewMalformed(command uint16, err error) *Malformed {
+	return &Malformed{
+		Command: command,
+		Error:   NewError(err),
+	}
+}
+
+func (m *Malformed) Payload() ([]byte, error) {
+	return JSON.Marshal(m)
+}
+
+// Read decodes JSON payload and validates it.
+func (m *Malformed) Read(data []byte) error {
+	return JSON.Unmarshal(data, m)
+}
+
+func (m *Malformed) Validate() error {
+	if m.Error == nil {
+		return errors.WithMessage(constants.ErrMalformed, "empty error")
+	}
+
+	return m.Error.Validate()
+}
+
+func (m *Malformed) writeBinary(w *binaryWriter) {
+	w.uvarint(uint64(m.Command))
+
+	if m.Error != nil {
+		m.Error.writeBinary(w)
+	} else {
+		(&Error{}).writeBinary(w)
+	}
+}
+
+func (m *Malformed) readBinary(r *binaryReader) {
+	m.Command = uint16(r.uvarintMax(math.MaxUint16))
+	m.Error = &Error{}
+	m.Error.readBinary(r)
+}
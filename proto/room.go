@@ -3,6 +3,7 @@ package proto
 import (
 	"encoding/json"
 
+	"github.com/opoccomaxao-go/rooms/constants"
 	"github.com/pkg/errors"
 )
 
@@ -12,16 +13,79 @@ type Room struct {
 	Clients  []*Client       `json:"clients"`
 	Endpoint string          `json:"endpoint,omitempty"`
 	Result   json.RawMessage `json:"result,omitempty"`
-	Error    string          `json:"error,omitempty"`
+	Error    *Error          `json:"error,omitempty"`
+	Trace    string          `json:"trace,omitempty"` // Trace is W3C traceparent of room creation.
 	ServerID uint64          `json:"-"`
 }
 
-func (r *Room) Payload() []byte {
-	res, _ := json.Marshal(r)
-
-	return res
+func (r *Room) Payload() ([]byte, error) {
+	return JSON.Marshal(r)
 }
 
+// Read decodes JSON payload and validates it.
 func (r *Room) Read(data []byte) error {
-	return errors.WithStack(json.Unmarshal(data, r))
+	return JSON.Unmarshal(data, r)
+}
+
+// Validate checks room id, clients with unique ids, result and error.
+func (r *Room) Validate() error {
+	if r.ID == 0 {
+		return errors.WithMessage(constants.ErrMalformed, "room id is zero")
+	}
+
+	clients := make(map[uint64]struct{}, len(r.Clients))
+
+	for _, client := range r.Clients {
+		if client == nil {
+			return errors.WithMessagef(constants.ErrMalformed, "room %d: empty client", r.ID)
+		}
+
+		err := client.Validate()
+		if err != nil {
+			return errors.WithMessagef(err, "room %d", r.ID)
+		}
+
+		if _, ok := clients[client.ID]; ok {
+			return errors.WithMessagef(constants.ErrMalformed, "room %d: duplicate client %d", r.ID, client.ID)
+		}
+
+		clients[client.ID] = struct{}{}
+	}
+
+	if len(r.Result) > 0 && !json.Valid(r.Result) {
+		return errors.WithMessagef(constants.ErrMalformed, "room %d: invalid result", r.ID)
+	}
+
+	if r.Error != nil {
+		err := r.Error.Validate()
+		if err != nil {
+			return errors.WithMessagef(err, "room %d", r.ID)
+		}
+	}
+
+	return nil
+}
+
+// validateRooms checks every room and uniqueness of room ids.
+func validateRooms(rooms []*Room) error {
+	ids := make(map[uint64]struct{}, len(rooms))
+
+	for _, room := range rooms {
+		if room == nil {
+			return errors.WithMessage(constants.ErrMalformed, "empty room")
+		}
+
+		err := room.Validate()
+		if err != nil {
+			return err
+		}
+
+		if _, ok := ids[room.ID]; ok {
+			return errors.WithMessagef(constants.ErrMalformed, "duplicate room %d", room.ID)
+		}
+
+		ids[room.ID] = struct{}{}
+	}
+
+	return nil
 }
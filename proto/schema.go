@@ -0,0 +1,236 @@
+package proto
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+)
+
+// Direction is sender of command.
+type Direction string
+
+const (
+	DirectionMaster  Direction = "master"  // DirectionMaster is command sent by master to session server.
+	DirectionSession Direction = "session" // DirectionSession is command sent by session server to master.
+)
+
+// PayloadKind describes encoding of command payload.
+type PayloadKind string
+
+const (
+	PayloadKindNone    PayloadKind = "none"    // PayloadKindNone is empty payload.
+	PayloadKindID      PayloadKind = "id"      // PayloadKindID is room id, uint64 big-endian.
+	PayloadKindHeader  PayloadKind = "header"  // PayloadKindHeader is reliable header of command, Nack appends error text.
+	PayloadKindCodec   PayloadKind = "codec"   // PayloadKindCodec is codec name as text.
+	PayloadKindMessage PayloadKind = "message" // PayloadKindMessage is message encoded with selected codec.
+	PayloadKindJSON    PayloadKind = "json"    // PayloadKindJSON is message always encoded with JSON codec.
+)
+
+// Field types of MessageSchema. Binary codec writes fields in schema order.
+const (
+	FieldUint    = "uint"    // FieldUint is unsigned integer, uvarint in binary.
+	FieldBool    = "bool"    // FieldBool is boolean, one byte in binary.
+	FieldString  = "string"  // FieldString is UTF-8 text, uvarint length prefix in binary.
+	FieldBytes   = "bytes"   // FieldBytes is base64 string in JSON, uvarint length prefix in binary.
+	FieldRawJSON = "json"    // FieldRawJSON is embedded JSON value, bytes in binary.
+	FieldMap     = "map"     // FieldMap is string to string map, count and sorted key-value pairs in binary.
+	FieldList    = "list"    // FieldList is list of Item messages, uvarint count prefix in binary.
+	FieldMessage = "message" // FieldMessage is Item message, optional one has presence byte in binary.
+)
+
+// ProtocolSchema is machine-readable protocol specification.
+type ProtocolSchema struct {
+	Version  string          `json:"version"`
+	Codecs   []string        `json:"codecs"`
+	Errors   []ErrorSchema   `json:"errors"`
+	Commands []CommandSchema `json:"commands"`
+	Custom   RangeSchema     `json:"custom"` // Custom is id range reserved for application commands.
+	Messages []MessageSchema `json:"messages"`
+}
+
+type RangeSchema struct {
+	Min uint16 `json:"min"`
+	Max uint16 `json:"max"`
+}
+
+type ErrorSchema struct {
+	Code      ErrorCode `json:"code"`
+	Name      string    `json:"name"`
+	Retryable bool      `json:"retryable,omitempty"`
+}
+
+type CommandSchema struct {
+	ID        uint16      `json:"id"`
+	Name      string      `json:"name"`
+	Direction Direction   `json:"direction"`
+	Payload   PayloadKind `json:"payload"`
+	Message   string      `json:"message,omitempty"`  // Message is name of payload message.
+	Optional  bool        `json:"optional,omitempty"` // Optional payload could be empty.
+	Reliable  bool        `json:"reliable,omitempty"` // Reliable payload is prefixed with header.
+}
+
+type MessageSchema struct {
+	Name   string        `json:"name"`
+	Fields []FieldSchema `json:"fields"`
+}
+
+type FieldSchema struct {
+	Name     string `json:"name"` // Name is JSON key.
+	Type     string `json:"type"`
+	Item     string `json:"item,omitempty"`     // Item is message name of list and message fields.
+	Optional bool   `json:"optional,omitempty"` // Optional field could be omitted in JSON.
+}
+
+// errorNames are names of error codes.
+var errorNames = []string{
+	ErrorCodeUnknown:         "Unknown",
+	ErrorCodeCapacity:        "Capacity",
+	ErrorCodeInvalid:         "Invalid",
+	ErrorCodeEngineInit:      "EngineInit",
+	ErrorCodeVersionMismatch: "VersionMismatch",
+	ErrorCodeBadToken:        "BadToken",
+}
+
+// masterCommands are commands sent by master.
+var masterCommands = []CommandSchema{
+	{ID: CommandMasterAuthRequired, Name: "AuthRequired", Payload: PayloadKindJSON, Message: "Error", Optional: true},
+	{ID: CommandMasterAuthSuccess, Name: "AuthSuccess", Payload: PayloadKindCodec, Optional: true},
+	{ID: CommandMasterRoomCreate, Name: "RoomCreate", Payload: PayloadKindMessage, Message: "Room", Reliable: true},
+	{ID: CommandMasterRoomCancel, Name: "RoomCancel", Payload: PayloadKindID, Reliable: true},
+	{ID: CommandMasterRoomAck, Name: "RoomAck", Payload: PayloadKindID},
+	{ID: CommandMasterAck, Name: "Ack", Payload: PayloadKindHeader},
+	{ID: CommandMasterNack, Name: "Nack", Payload: PayloadKindHeader},
+	{ID: CommandMasterRoomCreateBatch, Name: "RoomCreateBatch", Payload: PayloadKindMessage, Message: "RoomBatch", Reliable: true},
+	{ID: CommandMasterHeartbeat, Name: "Heartbeat", Payload: PayloadKindNone},
+	{ID: CommandMasterMalformed, Name: "Malformed", Payload: PayloadKindJSON, Message: "Malformed"},
+}
+
+// sessionCommands are commands sent by session server.
+var sessionCommands = []CommandSchema{
+	{ID: CommandSessionAuth, Name: "Auth", Payload: PayloadKindJSON, Message: "Auth"},
+	{ID: CommandSessionRoomCreated, Name: "RoomCreated", Payload: PayloadKindMessage, Message: "Room", Reliable: true},
+	{ID: CommandSessionRoomError, Name: "RoomError", Payload: PayloadKindMessage, Message: "Room"},
+	{ID: CommandSessionRoomFinished, Name: "RoomFinished", Payload: PayloadKindMessage, Message: "Room"},
+	{ID: CommandSessionStats, Name: "Stats", Payload: PayloadKindMessage, Message: "Stats"},
+	{ID: CommandSessionAck, Name: "Ack", Payload: PayloadKindHeader},
+	{ID: CommandSessionNack, Name: "Nack", Payload: PayloadKindHeader},
+	{ID: CommandSessionInventory, Name: "Inventory", Payload: PayloadKindMessage, Message: "Inventory"},
+	{ID: CommandSessionHeartbeat, Name: "Heartbeat", Payload: PayloadKindNone},
+	{ID: CommandSessionMalformed, Name: "Malformed", Payload: PayloadKindJSON, Message: "Malformed"},
+}
+
+// schemaMessages are all payload messages, fields are taken from declaration.
+var schemaMessages = []Message{
+	&Auth{},
+	&Client{},
+	&Error{},
+	&Inventory{},
+	&Malformed{},
+	&Room{},
+	&RoomBatch{},
+	&Stats{},
+}
+
+// Schema returns specification of protocol. It is built from command ids and message declarations.
+func Schema() *ProtocolSchema {
+	res := &ProtocolSchema{
+		Version: constants.Version,
+		Codecs:  []string{CodecNameJSON, CodecNameBinary},
+		Custom:  RangeSchema{Min: CommandCustomMin, Max: CommandCustomMax},
+	}
+
+	for code, name := range errorNames {
+		res.Errors = append(res.Errors, ErrorSchema{
+			Code:      ErrorCode(code),
+			Name:      name,
+			Retryable: ErrorCode(code).Retryable(),
+		})
+	}
+
+	for _, command := range masterCommands {
+		command.Direction = DirectionMaster
+		res.Commands = append(res.Commands, command)
+	}
+
+	for _, command := range sessionCommands {
+		command.Direction = DirectionSession
+		res.Commands = append(res.Commands, command)
+	}
+
+	for _, msg := range schemaMessages {
+		res.Messages = append(res.Messages, messageSchema(reflect.TypeOf(msg).Elem()))
+	}
+
+	return res
+}
+
+// Command returns schema of command. Returns false for unknown command.
+func (s *ProtocolSchema) Command(direction Direction, id uint16) (CommandSchema, bool) {
+	for _, command := range s.Commands {
+		if command.Direction == direction && command.ID == id {
+			return command, true
+		}
+	}
+
+	return CommandSchema{}, false
+}
+
+var (
+	typeRawJSON = reflect.TypeOf(json.RawMessage{})
+	typeBytes   = reflect.TypeOf([]byte{})
+)
+
+// messageSchema describes exported fields of message with JSON keys, fields without key aren't sent.
+func messageSchema(msgType reflect.Type) MessageSchema {
+	res := MessageSchema{
+		Name:   msgType.Name(),
+		Fields: []FieldSchema{},
+	}
+
+	for i := 0; i < msgType.NumField(); i++ {
+		field := msgType.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+
+		res.Fields = append(res.Fields, fieldSchema(name, options == "omitempty", field.Type))
+	}
+
+	return res
+}
+
+func fieldSchema(name string, optional bool, fieldType reflect.Type) FieldSchema {
+	res := FieldSchema{
+		Name:     name,
+		Optional: optional,
+	}
+
+	switch {
+	case fieldType == typeRawJSON:
+		res.Type = FieldRawJSON
+	case fieldType == typeBytes:
+		res.Type = FieldBytes
+	case fieldType.Kind() == reflect.Bool:
+		res.Type = FieldBool
+	case fieldType.Kind() == reflect.String:
+		res.Type = FieldString
+	case fieldType.Kind() == reflect.Map:
+		res.Type = FieldMap
+	case fieldType.Kind() == reflect.Slice:
+		res.Type = FieldList
+		res.Item = fieldType.Elem().Elem().Name()
+	case fieldType.Kind() == reflect.Pointer:
+		res.Type = FieldMessage
+		res.Item = fieldType.Elem().Name()
+	default:
+		res.Type = FieldUint
+	}
+
+	return res
+}
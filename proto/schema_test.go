@@ -0,0 +1,288 @@
+package proto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"flag"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	schemaFile = "../protocol.json"
+	schemaDoc  = "../protocol.md"
+)
+
+var updateSchema = flag.Bool("update", false, "rewrite "+schemaFile)
+
+func TestSchema(t *testing.T) {
+	t.Parallel()
+
+	schema := Schema()
+
+	messages := map[string]MessageSchema{}
+	for _, msg := range schema.Messages {
+		messages[msg.Name] = msg
+	}
+
+	for _, test := range testMessages() {
+		assert.Contains(t, messages, test.name)
+	}
+
+	for _, direction := range []Direction{DirectionMaster, DirectionSession} {
+		id := uint16(1)
+
+		for _, command := range schema.Commands {
+			if command.Direction != direction {
+				continue
+			}
+
+			assert.Equal(t, id, command.ID, "%s %s", direction, command.Name)
+
+			id++
+
+			if command.Message != "" {
+				assert.Contains(t, messages, command.Message, "%s %s", direction, command.Name)
+			}
+		}
+	}
+
+	for _, msg := range schema.Messages {
+		for _, field := range msg.Fields {
+			if field.Item != "" {
+				assert.Contains(t, messages, field.Item, "%s.%s", msg.Name, field.Name)
+			}
+		}
+	}
+
+	_, ok := schema.Command(DirectionSession, CommandSessionInventory)
+	assert.True(t, ok)
+
+	_, ok = schema.Command(DirectionMaster, 0)
+	assert.False(t, ok)
+}
+
+// TestSchemaFile checks that published schema is up to date, run with -update to rewrite it.
+func TestSchemaFile(t *testing.T) {
+	t.Parallel()
+
+	expected, err := json.MarshalIndent(Schema(), "", "  ")
+	require.NoError(t, err)
+
+	expected = append(expected, '\n')
+
+	if *updateSchema {
+		require.NoError(t, os.WriteFile(schemaFile, expected, 0o600))
+	}
+
+	actual, err := os.ReadFile(schemaFile)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(actual), "run go test ./proto -run TestSchemaFile -update")
+}
+
+// TestSchemaDoc checks command tables and command sections of documentation.
+func TestSchemaDoc(t *testing.T) {
+	t.Parallel()
+
+	file, err := os.Open(schemaDoc)
+	require.NoError(t, err)
+
+	defer file.Close()
+
+	var (
+		rowRegexp     = regexp.MustCompile(`^\| (\d+) +\| \[(\w+)\]\(#[\w-]+\) +\|$`)
+		idRegexp      = regexp.MustCompile(`^ID: (\d+)$`)
+		sectionRegexp = regexp.MustCompile(`^### (\w+)$`)
+		directions    = map[string]Direction{
+			"## Master commands":         DirectionMaster,
+			"## Session server commands": DirectionSession,
+		}
+
+		direction Direction
+		section   string
+		rows      []CommandSchema
+		sections  []CommandSchema
+	)
+
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if next, ok := directions[line]; ok {
+			direction = next
+
+			continue
+		}
+
+		if direction == "" {
+			continue
+		}
+
+		if match := rowRegexp.FindStringSubmatch(line); match != nil {
+			id, err := strconv.ParseUint(match[1], 10, 16)
+			require.NoError(t, err)
+
+			rows = append(rows, CommandSchema{ID: uint16(id), Name: match[2], Direction: direction})
+		}
+
+		if match := sectionRegexp.FindStringSubmatch(line); match != nil {
+			section = match[1]
+		}
+
+		if match := idRegexp.FindStringSubmatch(line); match != nil {
+			id, err := strconv.ParseUint(match[1], 10, 16)
+			require.NoError(t, err)
+
+			sections = append(sections, CommandSchema{ID: uint16(id), Name: section, Direction: direction})
+		}
+	}
+
+	require.NoError(t, scanner.Err())
+
+	expected := make([]CommandSchema, len(Schema().Commands))
+
+	for i, command := range Schema().Commands {
+		expected[i] = CommandSchema{ID: command.ID, Name: command.Name, Direction: command.Direction}
+	}
+
+	assert.Equal(t, expected, rows, "tables")
+	assert.Equal(t, expected, sections, "sections")
+}
+
+// TestSchemaBinary encodes JSON form of messages with schema and compares result with binary codec.
+func TestSchemaBinary(t *testing.T) {
+	t.Parallel()
+
+	messages := map[string]MessageSchema{}
+	for _, msg := range Schema().Messages {
+		messages[msg.Name] = msg
+	}
+
+	for _, test := range testMessages() {
+		data, err := JSON.Marshal(test.msg)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+
+		writeSchemaBinary(t, messages, test.name, data, &buf)
+
+		expected, err := Binary.Marshal(test.msg)
+		require.NoError(t, err)
+		assert.Equal(t, expected, buf.Bytes(), test.name)
+	}
+}
+
+// writeSchemaBinary writes JSON message as binary, only schema is used. Null message is written as empty one.
+func writeSchemaBinary(t *testing.T, messages map[string]MessageSchema, name string, data []byte, buf *bytes.Buffer) {
+	t.Helper()
+
+	var values map[string]json.RawMessage
+
+	require.NoError(t, json.Unmarshal(data, &values))
+
+	writeUvarint := func(value uint64) {
+		var res [binary.MaxVarintLen64]byte
+
+		buf.Write(res[:binary.PutUvarint(res[:], value)])
+	}
+
+	writeBytes := func(value []byte) {
+		writeUvarint(uint64(len(value)))
+		buf.Write(value)
+	}
+
+	msg, ok := messages[name]
+	require.True(t, ok, name)
+
+	for _, field := range msg.Fields {
+		value, ok := values[field.Name]
+		if !ok {
+			value = json.RawMessage("null")
+		}
+
+		switch field.Type {
+		case FieldUint:
+			var res uint64
+
+			require.NoError(t, json.Unmarshal(value, &res))
+			writeUvarint(res)
+		case FieldBool:
+			var res bool
+
+			require.NoError(t, json.Unmarshal(value, &res))
+
+			if res {
+				buf.WriteByte(1)
+			} else {
+				buf.WriteByte(0)
+			}
+		case FieldString:
+			var res string
+
+			require.NoError(t, json.Unmarshal(value, &res))
+			writeBytes([]byte(res))
+		case FieldBytes:
+			var res []byte
+
+			require.NoError(t, json.Unmarshal(value, &res))
+			writeBytes(res)
+		case FieldRawJSON:
+			if !ok {
+				value = nil
+			}
+
+			writeBytes(value)
+		case FieldMap:
+			var res map[string]string
+
+			require.NoError(t, json.Unmarshal(value, &res))
+
+			keys := make([]string, 0, len(res))
+			for key := range res {
+				keys = append(keys, key)
+			}
+
+			sort.Strings(keys)
+			writeUvarint(uint64(len(keys)))
+
+			for _, key := range keys {
+				writeBytes([]byte(key))
+				writeBytes([]byte(res[key]))
+			}
+		case FieldList:
+			var res []json.RawMessage
+
+			require.NoError(t, json.Unmarshal(value, &res))
+			writeUvarint(uint64(len(res)))
+
+			for _, item := range res {
+				writeSchemaBinary(t, messages, field.Item, item, buf)
+			}
+		case FieldMessage:
+			present := string(value) != "null"
+
+			if field.Optional {
+				if !present {
+					buf.WriteByte(0)
+
+					continue
+				}
+
+				buf.WriteByte(1)
+			}
+
+			writeSchemaBinary(t, messages, field.Item, value, buf)
+		default:
+			require.Fail(t, "unknown field type", "%s.%s: %s", name, field.Name, field.Type)
+		}
+	}
+}
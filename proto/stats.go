@@ -1,21 +1,18 @@
 package proto
 
-import (
-	"encoding/json"
-
-	"github.com/pkg/errors"
-)
-
 type Stats struct {
 	Capacity uint64 `json:"capacity"`
 }
 
-func (s *Stats) Payload() []byte {
-	res, _ := json.Marshal(s)
-
-	return res
+func (s *Stats) Payload() ([]byte, error) {
+	return JSON.Marshal(s)
 }
 
+// Read decodes JSON payload and validates it.
 func (s *Stats) Read(data []byte) error {
-	return errors.WithStack(json.Unmarshal(data, s))
+	return JSON.Unmarshal(data, s)
+}
+
+func (s *Stats) Validate() error {
+	return nil
 }
@@ -0,0 +1,58 @@
+package proto
+
+import (
+	"testing"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadID(t *testing.T) {
+	t.Parallel()
+
+	id, err := ReadID(PayloadID(42))
+	require.NoError(t, err)
+	assert.Equal(t, ID(42), id)
+
+	for _, payload := range [][]byte{nil, {1, 2, 3}, make([]byte, 9), PayloadID(0)} {
+		_, err := ReadID(payload)
+		assert.ErrorIs(t, err, constants.ErrMalformed, payload)
+		assert.ErrorIs(t, err, constants.ErrInvalid, payload)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	for name, msg := range map[string]Message{
+		"auth without token":    &Auth{Version: "3"},
+		"room without id":       &Room{},
+		"room with nil client":  &Room{ID: 1, Clients: []*Client{nil}},
+		"room with zero client": &Room{ID: 1, Clients: []*Client{{}}},
+		"room with duplicates":  &Room{ID: 1, Clients: []*Client{{ID: 1}, {ID: 1}}},
+		"room with bad result":  &Room{ID: 1, Result: []byte("{")},
+		"room with bad error":   &Room{ID: 1, Error: &Error{Code: errorCodeMax + 1}},
+		"empty batch":           &RoomBatch{},
+		"batch with duplicates": &RoomBatch{Rooms: []*Room{{ID: 1}, {ID: 1}}},
+		"inventory with nil":    &Inventory{Rooms: []*Room{nil}},
+		"malformed no error":    &Malformed{Command: 1},
+	} {
+		assert.ErrorIs(t, msg.Validate(), constants.ErrMalformed, name)
+	}
+
+	assert.NoError(t, testRoom().Validate())
+	assert.NoError(t, (&Inventory{}).Validate())
+}
+
+func TestCodec_Malformed(t *testing.T) {
+	t.Parallel()
+
+	for _, codec := range []Codec{JSON, Binary} {
+		data, err := codec.Marshal(&Room{ID: 1, Clients: []*Client{{ID: 1}, {ID: 1}}})
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, codec.Unmarshal(data, &Room{}), constants.ErrMalformed, codec.Name())
+		assert.ErrorIs(t, codec.Unmarshal([]byte{0xff}, &Room{}), constants.ErrMalformed, codec.Name())
+	}
+}
@@ -0,0 +1,38 @@
+package reliable
+
+import (
+	"encoding/binary"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/pkg/errors"
+)
+
+// HeaderSize is size of request header: sender epoch and request id.
+const HeaderSize = 16
+
+// Header identifies request. Epoch changes on every sender restart.
+type Header struct {
+	Epoch uint64
+	ID    uint64
+}
+
+func (h Header) Append(payload []byte) []byte {
+	res := make([]byte, HeaderSize, HeaderSize+len(payload))
+
+	binary.BigEndian.PutUint64(res, h.Epoch)
+	binary.BigEndian.PutUint64(res[HeaderSize/2:], h.ID)
+
+	return append(res, payload...)
+}
+
+// ReadHeader splits payload into header and body.
+func ReadHeader(payload []byte) (Header, []byte, error) {
+	if len(payload) < HeaderSize {
+		return Header{}, nil, errors.Wrapf(constants.ErrInvalid, "header size %d", len(payload))
+	}
+
+	return Header{
+		Epoch: binary.BigEndian.Uint64(payload),
+		ID:    binary.BigEndian.Uint64(payload[HeaderSize/2:]),
+	}, payload[HeaderSize:], nil
+}
@@ -0,0 +1,124 @@
+package reliable
+
+import (
+	"sync"
+
+	"github.com/opoccomaxao-go/ipc/event"
+)
+
+// Receiver acknowledges received events and suppresses duplicates.
+type Receiver struct {
+	send     SendFunc
+	ackType  uint16
+	nackType uint16
+
+	epoch uint64
+	base  uint64 // base is last id of continuous received sequence.
+	seen  map[uint64]struct{}
+	mu    sync.Mutex
+}
+
+func NewReceiver(send SendFunc, ackType uint16, nackType uint16) *Receiver {
+	return &Receiver{
+		send:     send,
+		ackType:  ackType,
+		nackType: nackType,
+		seen:     map[uint64]struct{}{},
+	}
+}
+
+// Wrap creates handler which acknowledges every event and passes only new ones into handler.
+func (r *Receiver) Wrap(handler func([]byte)) func([]byte) {
+	return r.WrapErr(func(payload []byte) error {
+		handler(payload)
+
+		return nil
+	})
+}
+
+// WrapErr creates handler which passes only new events into handler.
+// Events are acknowledged if handler succeeds, rejected with error text otherwise.
+// Event shorter than header is rejected with zero header.
+func (r *Receiver) WrapErr(handler func([]byte) error) func([]byte) {
+	return func(payload []byte) {
+		header, body, err := ReadHeader(payload)
+		if err != nil {
+			r.reply(r.nackType, Header{}, []byte(err.Error()))
+
+			return
+		}
+
+		if !r.accept(header) {
+			r.reply(r.ackType, header, nil)
+
+			return
+		}
+
+		err = handler(body)
+		if err != nil {
+			r.reply(r.nackType, header, []byte(err.Error()))
+
+			return
+		}
+
+		r.reply(r.ackType, header, nil)
+	}
+}
+
+func (r *Receiver) reply(eventType uint16, header Header, body []byte) {
+	_ = r.send(&event.Common{
+		Type:    eventType,
+		Payload: header.Append(body),
+	})
+}
+
+// accept marks request as received. Returns false for duplicates.
+func (r *Receiver) accept(header Header) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if header.Epoch != r.epoch {
+		r.epoch = header.Epoch
+		r.base = 0
+		r.seen = map[uint64]struct{}{}
+	}
+
+	if header.ID <= r.base {
+		return false
+	}
+
+	if _, ok := r.seen[header.ID]; ok {
+		return false
+	}
+
+	r.seen[header.ID] = struct{}{}
+
+	for {
+		if _, ok := r.seen[r.base+1]; !ok {
+			break
+		}
+
+		delete(r.seen, r.base+1)
+		r.base++
+	}
+
+	return true
+}
+
+// Take copies duplicate suppression state from other receiver.
+func (r *Receiver) Take(other *Receiver) {
+	if r == other {
+		return
+	}
+
+	other.mu.Lock()
+	defer other.mu.Unlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.epoch = other.epoch
+	r.base = other.base
+	r.seen = other.seen
+	other.seen = map[uint64]struct{}{}
+}
@@ -0,0 +1,172 @@
+package reliable
+
+import (
+	"testing"
+
+	"github.com/opoccomaxao-go/ipc/event"
+	"github.com/opoccomaxao-go/ipc/processor"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testTypeData uint16 = iota + 1
+	testTypeAck
+	testTypeNack
+)
+
+type testPeer struct {
+	outgoing []*event.Common
+	drop     bool
+}
+
+func (p *testPeer) send(event *event.Common) error {
+	if !p.drop {
+		p.outgoing = append(p.outgoing, event.Copy())
+	}
+
+	return nil
+}
+
+func (p *testPeer) flush(handler *processor.Processor) {
+	events := p.outgoing
+	p.outgoing = nil
+
+	for _, event := range events {
+		handler.Handle(event)
+	}
+}
+
+func TestSenderReceiver(t *testing.T) {
+	t.Parallel()
+
+	var (
+		senderPeer   testPeer
+		receiverPeer testPeer
+		received     []string
+		rejected     []string
+	)
+
+	sender := NewSender(senderPeer.send, func(event *event.Common, reason string) {
+		rejected = append(rejected, string(event.Payload)+":"+reason)
+	})
+	receiver := NewReceiver(receiverPeer.send, testTypeAck, testTypeNack)
+
+	receiverHandler := processor.New()
+	receiverHandler.Register(testTypeData, receiver.WrapErr(func(payload []byte) error {
+		if string(payload) == "bad" {
+			return errors.New("malformed")
+		}
+
+		received = append(received, string(payload))
+
+		return nil
+	}))
+
+	senderHandler := processor.New()
+	senderHandler.Register(testTypeAck, sender.OnAck)
+	senderHandler.Register(testTypeNack, sender.OnNack)
+
+	// lost on the wire.
+	senderPeer.drop = true
+	require.NoError(t, sender.Send(&event.Common{Type: testTypeData, Payload: []byte("1")}))
+	senderPeer.drop = false
+
+	require.NoError(t, sender.Send(&event.Common{Type: testTypeData, Payload: []byte("2")}))
+	require.NoError(t, sender.Send(&event.Common{Type: testTypeData, Payload: []byte("bad")}))
+	senderPeer.flush(receiverHandler)
+
+	// ack lost.
+	receiverPeer.outgoing = receiverPeer.outgoing[1:]
+	receiverPeer.flush(senderHandler)
+	assert.Equal(t, 2, sender.Pending())
+
+	require.NoError(t, sender.Resend())
+	senderPeer.flush(receiverHandler)
+	receiverPeer.flush(senderHandler)
+
+	assert.Zero(t, sender.Pending())
+	assert.Equal(t, []string{"2", "1"}, received)
+	assert.Equal(t, []string{"bad:malformed"}, rejected)
+
+	// restarted sender starts new epoch.
+	restarted := NewSender(senderPeer.send, nil)
+	require.NoError(t, restarted.Send(&event.Common{Type: testTypeData, Payload: []byte("3")}))
+	senderPeer.flush(receiverHandler)
+	assert.Equal(t, []string{"2", "1", "3"}, received)
+}
+
+func TestSender_Take(t *testing.T) {
+	t.Parallel()
+
+	var oldPeer, newPeer testPeer
+
+	oldSender := NewSender(oldPeer.send, nil)
+	require.NoError(t, oldSender.Send(&event.Common{Type: testTypeData, Payload: []byte("1")}))
+
+	newSender := NewSender(newPeer.send, nil)
+	newSender.Take(oldSender)
+
+	assert.Zero(t, oldSender.Pending())
+	assert.Equal(t, 1, newSender.Pending())
+
+	require.NoError(t, newSender.Resend())
+	require.Len(t, newPeer.outgoing, 1)
+	assert.Equal(t, oldPeer.outgoing, newPeer.outgoing)
+}
+
+// TestSender_TakeConcurrent checks Take during Ack, run with -race.
+func TestSender_TakeConcurrent(t *testing.T) {
+	t.Parallel()
+
+	sender := NewSender(func(*event.Common) error { return nil }, nil)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 100; i++ {
+			sender.Take(NewSender(func(*event.Common) error { return nil }, nil))
+		}
+	}()
+
+	for i := uint64(0); i < 100; i++ {
+		sender.OnAck(Header{ID: i}.Append(nil))
+	}
+
+	<-done
+}
+
+func TestReadHeader(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := ReadHeader(make([]byte, HeaderSize-1))
+	require.Error(t, err)
+
+	header, body, err := ReadHeader(Header{Epoch: 1, ID: 2}.Append([]byte("body")))
+	require.NoError(t, err)
+	assert.Equal(t, Header{Epoch: 1, ID: 2}, header)
+	assert.Equal(t, []byte("body"), body)
+}
+
+func TestReceiver_ShortPayload(t *testing.T) {
+	t.Parallel()
+
+	var peer testPeer
+
+	receiver := NewReceiver(peer.send, testTypeAck, testTypeNack)
+	receiver.WrapErr(func([]byte) error {
+		require.Fail(t, "payload without header is handled")
+
+		return nil
+	})([]byte{1, 2, 3})
+
+	require.Len(t, peer.outgoing, 1)
+	assert.Equal(t, testTypeNack, peer.outgoing[0].Type)
+
+	header, reason, err := ReadHeader(peer.outgoing[0].Payload)
+	require.NoError(t, err)
+	assert.Zero(t, header)
+	assert.NotEmpty(t, reason)
+}
@@ -0,0 +1,188 @@
+package reliable
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"sync"
+
+	"github.com/opoccomaxao-go/ipc/event"
+	"github.com/pkg/errors"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+type SendFunc func(*event.Common) error
+
+// NackFunc receives original event rejected by peer.
+type NackFunc func(event *event.Common, reason string)
+
+// EncodeFunc returns payload of event. It is called on every send, e.g. to encode payload with codec of connection.
+type EncodeFunc func() ([]byte, error)
+
+// Sender keeps sent events until peer acknowledges them.
+type Sender struct {
+	send    SendFunc
+	onNack  NackFunc
+	epoch   uint64
+	lastID  uint64
+	pending map[uint64]*pendingEvent
+	mu      sync.Mutex
+}
+
+type pendingEvent struct {
+	eventType uint16
+	encode    EncodeFunc
+	payload   []byte // payload is last sent payload without header.
+}
+
+func NewSender(send SendFunc, onNack NackFunc) *Sender {
+	var epoch [8]byte
+
+	_, _ = rand.Read(epoch[:])
+
+	return &Sender{
+		send:    send,
+		onNack:  onNack,
+		epoch:   binary.BigEndian.Uint64(epoch[:]),
+		pending: map[uint64]*pendingEvent{},
+	}
+}
+
+// Send stores event with new request id and sends it. Stored event is sent again on Resend until acknowledged.
+func (s *Sender) Send(source *event.Common) error {
+	payload := slices.Clone(source.Payload)
+
+	return s.SendEncoded(source.Type, func() ([]byte, error) {
+		return payload, nil
+	})
+}
+
+// SendEncoded stores event with new request id and sends it. Payload is encoded again on every Resend until acknowledged.
+// Event isn't stored if encode fails.
+func (s *Sender) SendEncoded(eventType uint16, encode EncodeFunc) error {
+	payload, err := encode()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	s.mu.Lock()
+	s.lastID++
+
+	header := Header{Epoch: s.epoch, ID: s.lastID}
+
+	s.pending[s.lastID] = &pendingEvent{
+		eventType: eventType,
+		encode:    encode,
+		payload:   payload,
+	}
+	s.mu.Unlock()
+
+	return errors.WithStack(s.send(&event.Common{
+		Type:    eventType,
+		Payload: header.Append(payload),
+	}))
+}
+
+// Resend encodes and sends all unacknowledged events in original order.
+func (s *Sender) Resend() error {
+	s.mu.Lock()
+	ids := maps.Keys(s.pending)
+	slices.Sort(ids)
+
+	headers := make([]Header, len(ids))
+	events := make([]*pendingEvent, len(ids))
+
+	for i, id := range ids {
+		headers[i] = Header{Epoch: s.epoch, ID: id}
+		events[i] = s.pending[id]
+	}
+	s.mu.Unlock()
+
+	for i, pending := range events {
+		payload, err := pending.encode()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		s.mu.Lock()
+		pending.payload = payload
+		s.mu.Unlock()
+
+		err = s.send(&event.Common{
+			Type:    pending.eventType,
+			Payload: headers[i].Append(payload),
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// Pending returns count of unacknowledged events.
+func (s *Sender) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.pending)
+}
+
+// Take moves all unacknowledged events from other sender to s. Other sender shouldn't be used after.
+func (s *Sender) Take(other *Sender) {
+	if s == other {
+		return
+	}
+
+	other.mu.Lock()
+	defer other.mu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.epoch = other.epoch
+	s.lastID = other.lastID
+	s.pending = other.pending
+	other.pending = map[uint64]*pendingEvent{}
+}
+
+func (s *Sender) remove(payload []byte) (*event.Common, []byte) {
+	header, body, err := ReadHeader(payload)
+	if err != nil {
+		return nil, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if header.Epoch != s.epoch {
+		return nil, nil
+	}
+
+	res, ok := s.pending[header.ID]
+	if !ok {
+		return nil, nil
+	}
+
+	delete(s.pending, header.ID)
+
+	return &event.Common{
+		Type:    res.eventType,
+		Payload: res.payload,
+	}, body
+}
+
+// OnAck is handler for ack command.
+func (s *Sender) OnAck(payload []byte) {
+	s.remove(payload)
+}
+
+// OnNack is handler for nack command.
+func (s *Sender) OnNack(payload []byte) {
+	source, reason := s.remove(payload)
+	if source == nil || s.onNack == nil {
+		return
+	}
+
+	s.onNack(source, string(reason))
+}
@@ -0,0 +1,41 @@
+package session
+
+import (
+	"github.com/opoccomaxao-go/ipc/event"
+	"github.com/opoccomaxao-go/rooms/proto"
+)
+
+// CommandHandler handles custom command of master. Payload is owned by handler.
+type CommandHandler func(payload []byte)
+
+// SendCommand sends custom command to master. Command isn't resent after reconnect.
+// Returns constants.ErrUnauthorized until AuthSuccess.
+func (s *Server) SendCommand(command uint16, payload []byte) error {
+	defer s.interval.Start("SendCommand").End()
+
+	err := proto.ValidateCustomCommand(command, payload)
+	if err != nil {
+		return err
+	}
+
+	return s.masterConn.Command(command, payload)
+}
+
+// onCommand creates handler of custom command.
+func (c *connWrapper) onCommand(handler CommandHandler) func([]byte) {
+	return func(payload []byte) {
+		defer c.interval.Start("onCommand").End()
+
+		handler(append([]byte(nil), payload...))
+	}
+}
+
+// Command sends custom command.
+func (c *connWrapper) Command(command uint16, payload []byte) error {
+	defer c.interval.Start("Command").End()
+
+	return c.send(&event.Common{
+		Type:    command,
+		Payload: payload,
+	})
+}
@@ -1,26 +1,95 @@
 package session
 
 import (
+	"context"
+	"sync"
+
 	"github.com/opoccomaxao-go/ipc/channel"
 	"github.com/opoccomaxao-go/ipc/event"
 	"github.com/opoccomaxao-go/ipc/processor"
 	"github.com/opoccomaxao-go/rooms/apm"
 	"github.com/opoccomaxao-go/rooms/constants"
 	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/opoccomaxao-go/rooms/reliable"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 )
 
 type connWrapper struct {
-	conn     *channel.Client
-	parent   *Server
-	logger   zerolog.Logger
-	interval apm.DebuggableInterval
+	conn       *channel.Client
+	parent     *Server
+	logger     zerolog.Logger
+	interval   apm.DebuggableInterval
+	sender     *reliable.Sender
+	receiver   *reliable.Receiver
+	lastSeen   int64       // lastSeen is unix time in nanoseconds, atomic.
+	codec      proto.Codec // codec encodes payloads, selected by master on AuthSuccess.
+	authorized bool        // authorized is true from AuthSuccess until AuthRequired, only Auth is sent otherwise.
+
+	mu sync.RWMutex
 }
 
 func (c *connWrapper) init() {
 	c.logger = c.parent.config.Logger.With().Logger()
-	c.interval = apm.NewZerologInterval(&c.logger, "session.connWrapper.")
+	c.interval = c.parent.config.Intervals("session.connWrapper.")
+	c.sender = reliable.NewSender(c.send, c.onNack)
+	c.receiver = reliable.NewReceiver(c.send, proto.CommandSessionAck, proto.CommandSessionNack)
+	c.codec = proto.JSON
+}
+
+func (c *connWrapper) getCodec() proto.Codec {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.codec
+}
+
+// setCodec switches codec after commands being sent with previous codec.
+func (c *connWrapper) setCodec(codec proto.Codec, authorized bool) {
+	c.mu.Lock()
+	c.codec = codec
+	c.authorized = authorized
+	c.mu.Unlock()
+}
+
+// sendMessage encodes msg with codec of connection and sends it. Encoding error is logged.
+// Message is dropped until AuthSuccess, master gets actual state after it.
+func (c *connWrapper) sendMessage(command uint16, msg proto.Message) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.authorized {
+		return
+	}
+
+	payload, err := c.codec.Marshal(msg)
+	if err != nil {
+		c.logger.Err(errors.WithStack(err)).Stack().Send()
+
+		return
+	}
+
+	c.conn.Send(&event.Common{
+		Type:    command,
+		Payload: payload,
+	})
+}
+
+// read decodes payload with codec of connection.
+func (c *connWrapper) read(payload []byte, msg proto.Message) error {
+	return errors.WithStack(c.getCodec().Unmarshal(payload, msg))
+}
+
+// send sends reliable command or reply. Commands are kept by sender until AuthSuccess.
+func (c *connWrapper) send(event *event.Common) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.authorized {
+		return errors.WithStack(constants.ErrUnauthorized)
+	}
+
+	return errors.WithStack(c.conn.Send(event))
 }
 
 func (c *connWrapper) Handler() channel.Handler[*event.Common] {
@@ -30,15 +99,30 @@ func (c *connWrapper) Handler() channel.Handler[*event.Common] {
 
 	res.Register(proto.CommandMasterAuthRequired, c.onAuthRequired)
 	res.Register(proto.CommandMasterAuthSuccess, c.onAuthSuccess)
-	res.Register(proto.CommandMasterRoomCreate, c.onRoomCreate)
-	res.Register(proto.CommandMasterRoomCancel, c.onRoomCancel)
+	res.Register(proto.CommandMasterRoomCreate, c.receiver.WrapErr(c.onRoomCreate))
+	res.Register(proto.CommandMasterRoomCreateBatch, c.receiver.WrapErr(c.onRoomCreateBatch))
+	res.Register(proto.CommandMasterRoomCancel, c.receiver.WrapErr(c.onRoomCancel))
+	res.Register(proto.CommandMasterRoomAck, c.onRoomAck)
+	res.Register(proto.CommandMasterAck, c.sender.OnAck)
+	res.Register(proto.CommandMasterNack, c.sender.OnNack)
+	res.Register(proto.CommandMasterHeartbeat, c.onHeartbeat)
+	res.Register(proto.CommandMasterMalformed, c.onMalformed)
+
+	for command, fn := range c.parent.config.Commands {
+		res.Register(command, c.onCommand(fn))
+	}
 
-	return res
+	return channel.HandlerFunc[*event.Common](func(event *event.Common) {
+		c.touch()
+		res.Handle(event)
+	})
 }
 
-func (c *connWrapper) Serve() error {
+func (c *connWrapper) Serve(ctx context.Context) error {
 	defer c.interval.Start("Serve").End()
 
+	go c.serveHeartbeat(ctx)
+
 	return c.conn.Serve(c.Handler())
 }
 
@@ -46,84 +130,265 @@ func (c *connWrapper) onAuthRequired(payload []byte) {
 	defer c.interval.Start("onAuthRequired").End()
 
 	if len(payload) == 0 {
+		// payloads are JSON until master selects codec, nothing is sent until AuthSuccess.
+		c.setCodec(proto.JSON, false)
+
 		c.Auth(&proto.Auth{
 			Version: constants.Version,
 			Token:   string(c.parent.config.Token),
+			Address: c.parent.config.Address,
+			Labels:  c.parent.config.Labels,
+			Codec:   c.parent.config.Codec.Name(),
 		})
-	} else {
-		c.parent.onAuthError(string(payload))
+
+		return
 	}
+
+	var authErr proto.Error
+
+	err := authErr.Read(payload)
+	if err != nil {
+		c.parent.onAuthError(errors.New(string(payload)))
+
+		return
+	}
+
+	c.parent.onAuthError(errors.WithStack(&authErr))
 }
 
-func (c *connWrapper) onAuthSuccess(_ []byte) {
+// onAuthSuccess switches to codec selected by master, payload is codec name.
+func (c *connWrapper) onAuthSuccess(payload []byte) {
 	defer c.interval.Start("onAuthSuccess").End()
 
-	c.Stats(&proto.Stats{
-		Capacity: c.parent.getCapacity(),
-	})
+	codec, err := proto.CodecByName(string(payload))
+	if err != nil {
+		c.logger.Err(err).Stack().Send()
+
+		codec = proto.JSON
+	}
+
+	c.setCodec(codec, true)
+
+	c.parent.reportStats()
+
+	c.resendQueue()
+
+	err = errors.WithStack(c.sender.Resend())
+	if err != nil {
+		c.logger.Err(err).Stack().Send()
+	}
+
+	c.Inventory(c.parent.inventory())
 }
 
-func (c *connWrapper) onRoomCreate(payload []byte) {
+// onRoomCreate handles reliable command, malformed one is rejected with Nack.
+func (c *connWrapper) onRoomCreate(payload []byte) error {
 	defer c.interval.Start("onRoomCreate").End()
 
 	var room proto.Room
 
-	err := errors.WithStack(room.Read(payload))
+	err := c.read(payload, &room)
 	if err != nil {
 		c.logger.Err(err).Stack().Send()
+
+		return err
 	}
 
 	c.parent.onRoomCreate(&room)
+
+	return nil
 }
 
-func (c *connWrapper) onRoomCancel(payload []byte) {
+// onRoomCreateBatch handles reliable command, malformed one is rejected with Nack.
+func (c *connWrapper) onRoomCreateBatch(payload []byte) error {
+	defer c.interval.Start("onRoomCreateBatch").End()
+
+	var batch proto.RoomBatch
+
+	err := c.read(payload, &batch)
+	if err != nil {
+		c.logger.Err(err).Stack().Send()
+
+		return err
+	}
+
+	c.parent.onRoomCreateBatch(batch.Rooms)
+
+	return nil
+}
+
+// onRoomCancel handles reliable command, malformed one is rejected with Nack.
+func (c *connWrapper) onRoomCancel(payload []byte) error {
 	defer c.interval.Start("onRoomCancel").End()
 
-	c.parent.onRoomCancel(proto.ReadID(payload))
+	roomID, err := proto.ReadID(payload)
+	if err != nil {
+		c.logger.Err(err).Stack().Send()
+
+		return err
+	}
+
+	c.parent.onRoomCancel(roomID)
+
+	return nil
+}
+
+// onMalformed logs command rejected by master.
+func (c *connWrapper) onMalformed(payload []byte) {
+	defer c.interval.Start("onMalformed").End()
+
+	var report proto.Malformed
+
+	err := report.Read(payload)
+	if err != nil {
+		c.logger.Err(err).Stack().Send()
+
+		return
+	}
+
+	c.logger.Error().
+		Uint16("type", report.Command).
+		Err(report.Error).
+		Msg("malformed")
+}
+
+// onNack handles rejected reliable commands.
+func (c *connWrapper) onNack(source *event.Common, reason string) {
+	defer c.interval.Start("onNack").End()
+
+	c.logger.Error().
+		Uint16("type", source.Type).
+		Str("reason", reason).
+		Msg("rejected")
+}
+
+func (c *connWrapper) onRoomAck(payload []byte) {
+	defer c.interval.Start("onRoomAck").End()
+
+	roomID, err := proto.ReadID(payload)
+	if err != nil {
+		c.logger.Err(err).Stack().Send()
+		c.Malformed(proto.CommandMasterRoomAck, err)
+
+		return
+	}
+
+	err = errors.WithStack(c.parent.config.Queue.Ack(roomID))
+	if err != nil {
+		c.logger.Err(err).Stack().Send()
+	}
+}
+
+// resendQueue sends all unacknowledged commands again.
+func (c *connWrapper) resendQueue() {
+	defer c.interval.Start("resendQueue").End()
+
+	items, err := c.parent.config.Queue.Items()
+	if err != nil {
+		c.logger.Err(errors.WithStack(err)).Stack().Send()
+
+		return
+	}
+
+	for _, item := range items {
+		c.sendMessage(item.Command, item.Room)
+	}
+}
+
+// sendQueued stores command in queue until acknowledged by master and sends it.
+// Command isn't sent if it can't be stored, e.g. queue returns constants.ErrOverflow.
+func (c *connWrapper) sendQueued(command uint16, room *proto.Room) error {
+	err := c.parent.config.Queue.Push(&QueueItem{
+		Command: command,
+		Room:    room,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	c.sendMessage(command, room)
+
+	return nil
 }
 
 func (c *connWrapper) Auth(auth *proto.Auth) {
 	defer c.interval.Start("Auth").End()
 
+	payload, err := auth.Payload()
+	if err != nil {
+		c.logger.Err(err).Stack().Send()
+
+		return
+	}
+
 	c.conn.Send(&event.Common{
 		Type:    proto.CommandSessionAuth,
-		Payload: auth.Payload(),
+		Payload: payload,
 	})
 }
 
 func (c *connWrapper) RoomCreated(room *proto.Room) {
 	defer c.interval.Start("RoomCreated").End()
 
-	c.conn.Send(&event.Common{
-		Type:    proto.CommandSessionRoomCreated,
-		Payload: room.Payload(),
-	})
+	encode, err := proto.NewEncoder(room, c.getCodec)
+	if err != nil {
+		c.logger.Err(errors.WithStack(err)).Stack().Send()
+
+		return
+	}
+
+	// sent on AuthSuccess if not authorized.
+	_ = c.sender.SendEncoded(proto.CommandSessionRoomCreated, encode)
 }
 
-func (c *connWrapper) RoomError(room *proto.Room) {
+func (c *connWrapper) RoomError(room *proto.Room) error {
 	defer c.interval.Start("RoomError").End()
 
-	c.conn.Send(&event.Common{
-		Type:    proto.CommandSessionRoomError,
-		Payload: room.Payload(),
-	})
+	return c.sendQueued(proto.CommandSessionRoomError, room)
 }
 
-func (c *connWrapper) RoomFinished(room *proto.Room) {
+func (c *connWrapper) RoomFinished(room *proto.Room) error {
 	defer c.interval.Start("RoomFinished").End()
 
-	c.conn.Send(&event.Common{
-		Type:    proto.CommandSessionRoomFinished,
-		Payload: room.Payload(),
-	})
+	return c.sendQueued(proto.CommandSessionRoomFinished, room)
 }
 
 func (c *connWrapper) Stats(stats *proto.Stats) {
 	defer c.interval.Start("Stats").End()
 
+	c.sendMessage(proto.CommandSessionStats, stats)
+}
+
+// Inventory reports all running rooms, split into pages if required.
+func (c *connWrapper) Inventory(rooms []*proto.Room) {
+	defer c.interval.Start("Inventory").End()
+
+	pages, err := proto.SplitInventory(rooms, proto.MaxPayloadSize)
+	if err != nil {
+		c.logger.Err(err).Stack().Send()
+
+		return
+	}
+
+	for _, page := range pages {
+		c.sendMessage(proto.CommandSessionInventory, page)
+	}
+}
+
+// Malformed reports rejected command with invalid payload to master.
+func (c *connWrapper) Malformed(command uint16, err error) {
+	defer c.interval.Start("Malformed").End()
+
+	payload, err := proto.NewMalformed(command, err).Payload()
+	if err != nil {
+		c.logger.Err(err).Stack().Send()
+
+		return
+	}
+
 	c.conn.Send(&event.Common{
-		Type:    proto.CommandSessionStats,
-		Payload: stats.Payload(),
+		Type:    proto.CommandSessionMalformed,
+		Payload: payload,
 	})
 }
 
@@ -0,0 +1,65 @@
+package session
+
+import (
+	"net"
+	"testing"
+
+	"github.com/opoccomaxao-go/ipc/event"
+	"github.com/opoccomaxao-go/ipc/transport"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/opoccomaxao-go/rooms/reliable"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConnWrapper_AuthSuccessCodec checks that nothing but Auth is sent until AuthSuccess
+// and pending commands are sent with codec selected by master.
+func TestConnWrapper_AuthSuccessCodec(t *testing.T) {
+	t.Parallel()
+
+	local, remote := net.Pipe()
+	defer remote.Close()
+
+	received := make(chan *event.Common, 10)
+
+	go func() {
+		peer := transport.NewSocket(remote)
+
+		var buffer event.Common
+
+		for peer.Read(&buffer) == nil {
+			received <- buffer.Copy()
+		}
+	}()
+
+	server := newFuzzServer(local)
+	conn := server.masterConn
+
+	room := fuzzRoom(1)
+
+	conn.onAuthRequired(nil)
+	conn.RoomCreated(room)
+	conn.Stats(&proto.Stats{Capacity: 1})
+
+	auth := <-received
+	assert.Equal(t, proto.CommandSessionAuth, auth.Type)
+	assert.Empty(t, received, "nothing is sent before AuthSuccess")
+
+	conn.onAuthSuccess([]byte(proto.CodecNameBinary))
+
+	var created *event.Common
+
+	for created == nil {
+		if next := <-received; next.Type == proto.CommandSessionRoomCreated {
+			created = next
+		}
+	}
+
+	_, body, err := reliable.ReadHeader(created.Payload)
+	require.NoError(t, err)
+
+	var res proto.Room
+
+	require.NoError(t, proto.Binary.Unmarshal(body, &res))
+	assert.Equal(t, room.ID, res.ID)
+}
@@ -0,0 +1,232 @@
+package session
+
+import (
+	"context"
+	"net"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opoccomaxao-go/ipc/channel"
+	"github.com/opoccomaxao-go/ipc/event"
+	"github.com/opoccomaxao-go/ipc/transport"
+	"github.com/opoccomaxao-go/rooms/apm"
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/engine/engtest"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/opoccomaxao-go/rooms/reliable"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fuzzCapacity = 2
+
+// fuzzCommands are all commands registered in connWrapper.Handler.
+// Payloads of reliable commands get valid header, so fuzzer reaches handlers.
+var fuzzCommands = []struct {
+	command  uint16
+	reliable bool
+}{
+	{command: proto.CommandMasterAuthRequired},
+	{command: proto.CommandMasterAuthSuccess},
+	{command: proto.CommandMasterRoomCreate, reliable: true},
+	{command: proto.CommandMasterRoomCancel, reliable: true},
+	{command: proto.CommandMasterRoomAck},
+	{command: proto.CommandMasterAck},
+	{command: proto.CommandMasterNack},
+	{command: proto.CommandMasterRoomCreateBatch, reliable: true},
+	{command: proto.CommandMasterHeartbeat},
+	{command: proto.CommandMasterMalformed},
+}
+
+func fuzzRoom(id proto.ID) *proto.Room {
+	return &proto.Room{
+		ID: id,
+		Clients: []*proto.Client{
+			{ID: 1, Token: []byte("token"), Team: 1},
+			{ID: 2, Team: 2},
+		},
+	}
+}
+
+func fuzzEncode(t testing.TB, codec proto.Codec, msg proto.Message) []byte {
+	t.Helper()
+
+	res, err := codec.Marshal(msg)
+	require.NoError(t, err)
+
+	return res
+}
+
+// fuzzSeeds returns valid payloads of command.
+func fuzzSeeds(t testing.TB, command uint16, codec proto.Codec) [][]byte {
+	t.Helper()
+
+	switch command {
+	case proto.CommandMasterAuthRequired:
+		return [][]byte{fuzzEncode(t, proto.JSON, proto.NewError(constants.ErrBadToken))}
+	case proto.CommandMasterAuthSuccess:
+		return [][]byte{[]byte(codec.Name())}
+	case proto.CommandMasterRoomCreate:
+		return [][]byte{fuzzEncode(t, codec, fuzzRoom(1))}
+	case proto.CommandMasterRoomCancel, proto.CommandMasterRoomAck:
+		return [][]byte{proto.PayloadID(1)}
+	case proto.CommandMasterAck:
+		return [][]byte{reliable.Header{ID: 1}.Append(nil)}
+	case proto.CommandMasterNack:
+		return [][]byte{reliable.Header{ID: 1}.Append([]byte("rejected"))}
+	case proto.CommandMasterRoomCreateBatch:
+		return [][]byte{fuzzEncode(t, codec, &proto.RoomBatch{
+			Rooms: []*proto.Room{fuzzRoom(1), fuzzRoom(2), fuzzRoom(3)},
+		})}
+	case proto.CommandMasterMalformed:
+		return [][]byte{fuzzEncode(t, proto.JSON, proto.NewMalformed(proto.CommandSessionStats, constants.ErrMalformed))}
+	default:
+		return nil
+	}
+}
+
+// newFuzzServer creates session server connected to master with in-memory connection.
+func newFuzzServer(conn net.Conn) *Server {
+	logger := zerolog.Nop()
+
+	res := &Server{
+		config: Config{
+			Token:             []byte("token"),
+			EngineFactory:     engtest.New(),
+			Queue:             NewMemoryQueue(DefaultQueueCapacity),
+			Capacity:          fuzzCapacity,
+			Codec:             proto.JSON,
+			Metrics:           apm.NewRegistry(),
+			HeartbeatInterval: constants.DefaultHeartbeatInterval,
+			HeartbeatMisses:   constants.DefaultHeartbeatMisses,
+			Logger:            &logger,
+			Intervals:         apm.ZerologIntervals(&logger),
+			Tracer:            apm.NopTracer(),
+		},
+		masterConn: &connWrapper{},
+		rooms:      map[uint64]*roomWrapper{},
+		closed:     make(chan struct{}),
+		condRooms:  sync.NewCond(&sync.Mutex{}),
+	}
+
+	res.interval = res.config.Intervals("session.Server.")
+	res.initMetrics()
+
+	res.masterConn.parent = res
+	res.masterConn.init()
+	res.masterConn.conn = &channel.Client{
+		Channel: &channel.Channel{
+			Transport: transport.NewSocket(conn),
+			TryCount:  1,
+		},
+	}
+
+	return res
+}
+
+// FuzzConnWrapper sends arbitrary payload of every command to authenticated session server over in-memory connection.
+// Session server must not panic, must keep rooms within capacity and must release all goroutines after rooms finish.
+func FuzzConnWrapper(f *testing.F) {
+	for index, command := range fuzzCommands {
+		for _, codec := range []proto.Codec{proto.JSON, proto.Binary} {
+			binary := codec == proto.Binary
+
+			f.Add(uint8(index), binary, []byte{})
+
+			for _, payload := range fuzzSeeds(f, command.command, codec) {
+				f.Add(uint8(index), binary, payload)
+			}
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, index uint8, binary bool, payload []byte) {
+		command := fuzzCommands[int(index)%len(fuzzCommands)]
+
+		codec := proto.JSON
+		if binary {
+			codec = proto.Binary
+		}
+
+		if command.reliable {
+			payload = reliable.Header{Epoch: 1, ID: 1}.Append(payload)
+		}
+
+		goroutines := runtime.NumGoroutine()
+
+		local, remote := net.Pipe()
+		peer := transport.NewSocket(remote)
+		server := newFuzzServer(local)
+
+		ctx, cancelFn := context.WithCancel(context.Background())
+		done := make(chan struct{})
+
+		go func() {
+			defer close(done)
+
+			_ = server.Serve(ctx)
+		}()
+
+		go func() {
+			var buffer event.Common
+
+			for peer.Read(&buffer) == nil {
+			}
+		}()
+
+		send := func(command uint16, payload []byte) error {
+			return peer.Write(&event.Common{
+				Type:    command,
+				Payload: payload,
+			})
+		}
+
+		require.NoError(t, send(proto.CommandMasterAuthRequired, nil))
+		require.NoError(t, send(proto.CommandMasterAuthSuccess, []byte(codec.Name())))
+		require.NoError(t, send(command.command, payload))
+		// heartbeat is read after previous command is handled, fails if command closed connection.
+		_ = send(proto.CommandMasterHeartbeat, nil)
+
+		server.mu.RLock()
+		assert.LessOrEqual(t, len(server.rooms), fuzzCapacity, "capacity")
+
+		for id, room := range server.rooms {
+			assert.Equal(t, id, room.roomData.ID, "room %d", id)
+			assert.NoError(t, room.roomData.Validate(), "room %d", id)
+		}
+		server.mu.RUnlock()
+
+		require.NoError(t, peer.Close())
+		cancelFn()
+		<-done
+
+		assert.Eventually(t, func() bool {
+			server.mu.RLock()
+			defer server.mu.RUnlock()
+
+			return len(server.rooms) == 0
+		}, constants.DefaultTimeout, time.Millisecond, "rooms finished")
+
+		requireGoroutines(t, goroutines)
+	})
+}
+
+// requireGoroutines waits until count of goroutines is not greater than expected.
+func requireGoroutines(t *testing.T, expected int) {
+	t.Helper()
+
+	deadline := time.Now().Add(constants.DefaultTimeout)
+
+	for runtime.NumGoroutine() > expected {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<20)
+
+			require.Failf(t, "goroutine leak", "%d > %d\n%s",
+				runtime.NumGoroutine(), expected, buf[:runtime.Stack(buf, true)])
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
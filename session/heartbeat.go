@@ -0,0 +1,67 @@
+package session
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/opoccomaxao-go/ipc/event"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/pkg/errors"
+)
+
+// touch marks master as alive.
+func (c *connWrapper) touch() {
+	atomic.StoreInt64(&c.lastSeen, time.Now().UnixNano())
+}
+
+func (c *connWrapper) LastSeen() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&c.lastSeen))
+}
+
+func (c *connWrapper) onHeartbeat(_ []byte) {}
+
+func (c *connWrapper) Heartbeat() {
+	defer c.interval.Start("Heartbeat").End()
+
+	c.conn.Send(&event.Common{
+		Type: proto.CommandSessionHeartbeat,
+	})
+}
+
+// serveHeartbeat sends heartbeats and drops connection after HeartbeatMisses intervals of silence to force reconnect.
+func (c *connWrapper) serveHeartbeat(ctx context.Context) {
+	defer c.interval.Start("serveHeartbeat").End()
+
+	interval := c.parent.config.HeartbeatInterval
+	timeout := interval * time.Duration(c.parent.config.HeartbeatMisses)
+
+	c.touch()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if now.Sub(c.LastSeen()) > timeout {
+				c.logger.Warn().
+					Time("last_seen", c.LastSeen()).
+					Msg("heartbeat timeout")
+
+				err := errors.WithStack(c.conn.Transport.Close())
+				if err != nil {
+					c.logger.Err(err).Stack().Send()
+				}
+
+				c.touch()
+
+				continue
+			}
+
+			c.Heartbeat()
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package session
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/opoccomaxao-go/ipc/event"
+	"github.com/opoccomaxao-go/ipc/transport"
+	"github.com/opoccomaxao-go/rooms/engine/engtest"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestServer_HeartbeatTimeout checks reconnect to silent master.
+func TestServer_HeartbeatTimeout(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	defer listener.Close()
+
+	server, err := New(Config{
+		MasterAddress:     listener.Addr().String(),
+		Token:             []byte("token"),
+		EngineFactory:     engtest.New(),
+		ReconnectTimeout:  time.Millisecond * 10,
+		HeartbeatInterval: time.Millisecond * 10,
+		HeartbeatMisses:   2,
+	})
+	require.NoError(t, err)
+
+	go func() {
+		_ = server.Serve(ctx)
+	}()
+
+	require.NoError(t, listener.(*net.TCPListener).SetDeadline(time.Now().Add(time.Second)))
+
+	conn, err := listener.Accept()
+	require.NoError(t, err)
+
+	defer conn.Close()
+
+	require.NoError(t, conn.SetDeadline(time.Now().Add(time.Second)))
+
+	// master doesn't reply, session server sends heartbeats until timeout and drops connection.
+	master := transport.NewSocket(conn)
+	heartbeats := 0
+
+	for {
+		var received event.Common
+
+		if master.Read(&received) != nil {
+			break
+		}
+
+		if received.Type == proto.CommandSessionHeartbeat {
+			heartbeats++
+		}
+	}
+
+	assert.NotZero(t, heartbeats)
+
+	conn, err = listener.Accept()
+	require.NoError(t, err, "reconnect")
+
+	defer conn.Close()
+}
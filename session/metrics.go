@@ -0,0 +1,82 @@
+package session
+
+import (
+	"github.com/opoccomaxao-go/rooms/apm"
+	"github.com/opoccomaxao-go/rooms/engine"
+)
+
+type metrics struct {
+	roomsCreated  *apm.Counter
+	roomsFailed   *apm.Counter
+	roomsFinished *apm.Counter
+	engineTick    *apm.Histogram
+}
+
+// Metrics returns registry of session server metrics.
+func (s *Server) Metrics() *apm.Registry {
+	return s.config.Metrics
+}
+
+func (s *Server) initMetrics() {
+	registry := s.config.Metrics
+
+	s.metrics = metrics{
+		roomsCreated: registry.Counter(
+			"rooms_session_rooms_created_total", "Count of created rooms.",
+		),
+		roomsFailed: registry.Counter(
+			"rooms_session_rooms_failed_total", "Count of rooms failed to create.",
+		),
+		roomsFinished: registry.Counter(
+			"rooms_session_rooms_finished_total", "Count of finished rooms.",
+		),
+		engineTick: registry.Histogram(
+			"rooms_session_engine_tick_duration_seconds", "Duration of engine tick.", nil,
+		),
+	}
+
+	registry.GaugeFunc(
+		"rooms_session_rooms_active", "Count of running rooms.",
+		nil, s.roomsActiveSamples,
+	)
+	registry.GaugeFunc(
+		"rooms_session_capacity", "Count of rooms which could be created.",
+		nil, s.capacitySamples,
+	)
+	registry.GaugeFunc(
+		"rooms_session_clients_active", "Count of clients in running rooms.",
+		nil, s.clientsActiveSamples,
+	)
+}
+
+// instrument provides tick observer to engine if supported.
+func (s *Server) instrument(instance engine.Engine) {
+	if instrumented, ok := instance.(engine.Instrumented); ok {
+		instrumented.Instrument(s.metrics.engineTick.ObserveDuration)
+	}
+}
+
+func (s *Server) roomsActiveSamples() []apm.Sample {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return []apm.Sample{{Value: float64(len(s.rooms))}}
+}
+
+func (s *Server) capacitySamples() []apm.Sample {
+	return []apm.Sample{{Value: float64(s.getCapacity())}}
+}
+
+// clientsActiveSamples sums clients of all rooms, per room series would grow with every room id.
+func (s *Server) clientsActiveSamples() []apm.Sample {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	clients := 0
+
+	for _, room := range s.rooms {
+		clients += len(room.clients)
+	}
+
+	return []apm.Sample{{Value: float64(clients)}}
+}
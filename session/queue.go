@@ -0,0 +1,23 @@
+package session
+
+import (
+	"github.com/opoccomaxao-go/rooms/proto"
+)
+
+const DefaultQueueCapacity = 1000
+
+// QueueItem is unacknowledged command to master.
+type QueueItem struct {
+	Command uint16      `json:"command"`
+	Room    *proto.Room `json:"room"`
+}
+
+// Queue keeps RoomFinished/RoomError commands until master acknowledges them by room id.
+type Queue interface {
+	// Push adds item or replaces existing item of the same room.
+	Push(item *QueueItem) error
+	// Ack removes item of room.
+	Ack(roomID uint64) error
+	// Items returns all unacknowledged items in push order.
+	Items() ([]*QueueItem, error)
+}
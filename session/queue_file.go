@@ -0,0 +1,128 @@
+package session
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/utils"
+	"github.com/pkg/errors"
+)
+
+// FileQueue is MemoryQueue backed by append-only JSON lines file.
+// File is truncated when all items are acknowledged.
+type FileQueue struct {
+	*MemoryQueue
+
+	file    *os.File
+	encoder *json.Encoder
+	mu      sync.Mutex
+}
+
+type queueRecord struct {
+	Item *QueueItem `json:"item,omitempty"`
+	Ack  uint64     `json:"ack,omitempty"`
+}
+
+// implements interface.
+var _ Queue = (*FileQueue)(nil)
+
+// NewFileQueue opens or creates file and restores unacknowledged items from it.
+// Zero capacity means unlimited.
+func NewFileQueue(path string, capacity int) (*FileQueue, error) {
+	//nolint:gomnd // file permissions.
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := &FileQueue{
+		MemoryQueue: NewMemoryQueue(0),
+		file:        file,
+		encoder:     json.NewEncoder(file),
+	}
+
+	err = res.restore()
+	if err != nil {
+		_ = file.Close()
+
+		return nil, err
+	}
+
+	res.MemoryQueue.capacity = capacity
+
+	return res, nil
+}
+
+// restore applies all records of file, partial last record left by crash is dropped.
+func (q *FileQueue) restore() error {
+	return utils.ReadLines(q.file, func(line []byte) error {
+		var record queueRecord
+
+		err := json.Unmarshal(line, &record)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if record.Item == nil {
+			q.MemoryQueue.ack(record.Ack)
+
+			return nil
+		}
+
+		if record.Item.Room == nil {
+			return errors.Wrap(constants.ErrInvalid, "queue item")
+		}
+
+		return q.MemoryQueue.push(record.Item)
+	})
+}
+
+func (q *FileQueue) Push(item *QueueItem) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.MemoryQueue.mu.Lock()
+	defer q.MemoryQueue.mu.Unlock()
+
+	if q.MemoryQueue.index(item.Room.ID) == -1 &&
+		q.MemoryQueue.capacity > 0 &&
+		len(q.MemoryQueue.items) >= q.MemoryQueue.capacity {
+		return errors.Wrapf(constants.ErrOverflow, "queue capacity %d", q.MemoryQueue.capacity)
+	}
+
+	err := q.encoder.Encode(&queueRecord{Item: item})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return q.MemoryQueue.push(item)
+}
+
+func (q *FileQueue) Ack(roomID uint64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.MemoryQueue.mu.Lock()
+	defer q.MemoryQueue.mu.Unlock()
+
+	if q.MemoryQueue.index(roomID) == -1 {
+		return nil
+	}
+
+	q.MemoryQueue.ack(roomID)
+
+	if len(q.MemoryQueue.items) == 0 {
+		return errors.WithStack(q.file.Truncate(0))
+	}
+
+	return errors.WithStack(q.encoder.Encode(&queueRecord{Ack: roomID}))
+}
+
+func (q *FileQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return errors.WithStack(q.file.Close())
+}
@@ -0,0 +1,83 @@
+package session
+
+import (
+	"sync"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/pkg/errors"
+	"golang.org/x/exp/slices"
+)
+
+type MemoryQueue struct {
+	capacity int
+	items    []*QueueItem
+	mu       sync.Mutex
+}
+
+// implements interface.
+var _ Queue = (*MemoryQueue)(nil)
+
+// NewMemoryQueue creates Queue which keeps up to capacity items. Zero capacity means unlimited.
+func NewMemoryQueue(capacity int) *MemoryQueue {
+	return &MemoryQueue{
+		capacity: capacity,
+	}
+}
+
+func (q *MemoryQueue) index(roomID uint64) int {
+	return slices.IndexFunc(q.items, func(item *QueueItem) bool {
+		return item.Room.ID == roomID
+	})
+}
+
+func (q *MemoryQueue) Push(item *QueueItem) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.push(item)
+}
+
+func (q *MemoryQueue) push(item *QueueItem) error {
+	if index := q.index(item.Room.ID); index != -1 {
+		q.items[index] = item
+
+		return nil
+	}
+
+	if q.capacity > 0 && len(q.items) >= q.capacity {
+		return errors.Wrapf(constants.ErrOverflow, "queue capacity %d", q.capacity)
+	}
+
+	q.items = append(q.items, item)
+
+	return nil
+}
+
+func (q *MemoryQueue) Ack(roomID uint64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.ack(roomID)
+
+	return nil
+}
+
+func (q *MemoryQueue) ack(roomID uint64) {
+	if index := q.index(roomID); index != -1 {
+		q.items = slices.Delete(q.items, index, index+1)
+	}
+}
+
+func (q *MemoryQueue) Items() ([]*QueueItem, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return append([]*QueueItem(nil), q.items...), nil
+}
+
+func (q *MemoryQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.items)
+}
@@ -0,0 +1,120 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testQueue(t *testing.T, queue Queue) {
+	t.Helper()
+
+	for i := uint64(1); i <= 3; i++ {
+		require.NoError(t, queue.Push(&QueueItem{
+			Command: proto.CommandSessionRoomFinished,
+			Room:    &proto.Room{ID: i},
+		}))
+	}
+
+	require.NoError(t, queue.Push(&QueueItem{
+		Command: proto.CommandSessionRoomError,
+		Room:    &proto.Room{ID: 2, Error: &proto.Error{Message: "failed"}},
+	}))
+
+	require.ErrorIs(t, queue.Push(&QueueItem{
+		Command: proto.CommandSessionRoomFinished,
+		Room:    &proto.Room{ID: 4},
+	}), constants.ErrOverflow)
+
+	require.NoError(t, queue.Ack(1))
+	require.NoError(t, queue.Ack(5))
+
+	items, err := queue.Items()
+	require.NoError(t, err)
+	assert.Equal(t, []*QueueItem{
+		{Command: proto.CommandSessionRoomError, Room: &proto.Room{ID: 2, Error: &proto.Error{Message: "failed"}}},
+		{Command: proto.CommandSessionRoomFinished, Room: &proto.Room{ID: 3}},
+	}, items)
+}
+
+func TestMemoryQueue(t *testing.T) {
+	t.Parallel()
+
+	testQueue(t, NewMemoryQueue(3))
+}
+
+func TestFileQueue(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "queue.jsonl")
+
+	queue, err := NewFileQueue(path, 3)
+	require.NoError(t, err)
+
+	testQueue(t, queue)
+	require.NoError(t, queue.Close())
+
+	restored, err := NewFileQueue(path, 3)
+	require.NoError(t, err)
+
+	items, err := restored.Items()
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, uint64(2), items[0].Room.ID)
+	assert.Equal(t, "failed", items[0].Room.Error.Error())
+
+	require.NoError(t, restored.Ack(2))
+	require.NoError(t, restored.Ack(3))
+	require.NoError(t, restored.Close())
+
+	restored, err = NewFileQueue(path, 3)
+	require.NoError(t, err)
+
+	defer restored.Close()
+
+	assert.Zero(t, restored.Len())
+}
+
+func TestFileQueue_PartialRecord(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "queue.jsonl")
+
+	queue, err := NewFileQueue(path, 0)
+	require.NoError(t, err)
+	require.NoError(t, queue.Push(&QueueItem{
+		Command: proto.CommandSessionRoomFinished,
+		Room:    &proto.Room{ID: 1},
+	}))
+	require.NoError(t, queue.Close())
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+
+	_, err = file.WriteString(`{"item":{"comm`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	queue, err = NewFileQueue(path, 0)
+	require.NoError(t, err)
+	require.NoError(t, queue.Push(&QueueItem{
+		Command: proto.CommandSessionRoomFinished,
+		Room:    &proto.Room{ID: 2},
+	}))
+	require.NoError(t, queue.Close())
+
+	restored, err := NewFileQueue(path, 0)
+	require.NoError(t, err)
+
+	defer restored.Close()
+
+	items, err := restored.Items()
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, uint64(2), items[1].Room.ID)
+}
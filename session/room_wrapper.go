@@ -23,7 +23,7 @@ type roomWrapper struct {
 
 func (r *roomWrapper) init() {
 	r.logger = r.parent.config.Logger.With().Logger()
-	r.interval = apm.NewZerologInterval(&r.logger, "session.roomWrapper.")
+	r.interval = r.parent.config.Intervals("session.roomWrapper.")
 
 	clientsTotal := len(r.roomData.Clients)
 
@@ -3,10 +3,13 @@ package session
 import (
 	"context"
 	"net"
+	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/opoccomaxao-go/ipc/channel"
+	"github.com/opoccomaxao-go/ipc/transport"
 	"github.com/opoccomaxao-go/rooms/apm"
 	"github.com/opoccomaxao-go/rooms/constants"
 	"github.com/opoccomaxao-go/rooms/engine"
@@ -16,11 +19,21 @@ import (
 	"github.com/rs/zerolog"
 )
 
+const DefaultCapacity = 1
+
+// resultRetryInterval is delay before next attempt to store room result in full queue.
+const resultRetryInterval = 100 * time.Millisecond
+
 type Server struct {
-	config     Config
-	interval   apm.DebuggableInterval
-	masterConn *connWrapper
-	rooms      []*roomWrapper
+	config        Config
+	interval      apm.DebuggableInterval
+	masterConn    *connWrapper
+	metricsServer *http.Server // metricsServer is Prometheus metrics HTTP listener.
+	metrics       metrics
+	rooms         map[uint64]*roomWrapper
+	authErr       error
+	closed        chan struct{} // closed is closed by Close.
+	closeOnce     sync.Once
 
 	condRooms *sync.Cond
 
@@ -32,8 +45,28 @@ type Config struct {
 	Token            []byte         // Token is auth token.
 	ReconnectTimeout time.Duration  // optional. Default = constants.DefaultTimeoutReconnect
 	EngineFactory    engine.Factory // EngineFactory constructs new Engine instance.
+	Queue            Queue          // optional. Keeps room results until acknowledged. Default = NewMemoryQueue(DefaultQueueCapacity)
+	Capacity         uint64         // optional. Max count of running rooms. Default = DefaultCapacity
+	Codec            proto.Codec    // optional. Payload codec requested from master, e.g. proto.Binary. Default = proto.JSON
+
+	Address string            // optional. Address is endpoint advertised to master, shown in admin API.
+	Labels  map[string]string // optional. Labels are shown in admin API.
+
+	Metrics        *apm.Registry // optional. Registry of session server metrics. Default = apm.NewRegistry()
+	MetricsAddress string        // optional. Prometheus metrics HTTP listener is disabled if empty.
 
-	Logger *zerolog.Logger
+	// HeartbeatInterval is interval between heartbeats. Default = constants.DefaultHeartbeatInterval
+	HeartbeatInterval time.Duration
+	// HeartbeatMisses is count of missed heartbeats before reconnect. Default = constants.DefaultHeartbeatMisses
+	HeartbeatMisses int
+
+	Logger    *zerolog.Logger
+	Intervals apm.IntervalFactory // optional. Measures methods. Default = apm.ZerologIntervals(Logger)
+	Tracer    apm.Tracer          // optional. Traces room creation. Default = apm.NopTracer()
+
+	// Commands are handlers of custom commands from master, ids are in custom range of proto.
+	// optional. Custom commands are ignored if nil.
+	Commands map[uint16]CommandHandler
 }
 
 func New(cfg Config) (*Server, error) {
@@ -49,22 +82,73 @@ func New(cfg Config) (*Server, error) {
 		return nil, errors.WithMessage(constants.ErrNoParam, "EngineFactory")
 	}
 
+	for command := range cfg.Commands {
+		err := proto.ValidateCustomCommand(command, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if cfg.ReconnectTimeout <= 0 {
 		cfg.ReconnectTimeout = constants.DefaultTimeoutReconnect
 	}
 
+	if cfg.HeartbeatInterval <= 0 {
+		cfg.HeartbeatInterval = constants.DefaultHeartbeatInterval
+	}
+
+	if cfg.HeartbeatMisses <= 0 {
+		cfg.HeartbeatMisses = constants.DefaultHeartbeatMisses
+	}
+
+	if cfg.Capacity == 0 {
+		cfg.Capacity = DefaultCapacity
+	}
+
+	if cfg.Codec == nil {
+		cfg.Codec = proto.JSON
+	}
+
+	if cfg.Queue == nil {
+		cfg.Queue = NewMemoryQueue(DefaultQueueCapacity)
+	}
+
+	if cfg.Metrics == nil {
+		cfg.Metrics = apm.NewRegistry()
+	}
+
 	if cfg.Logger == nil {
 		logger := zerolog.Nop()
 		cfg.Logger = &logger
 	}
 
+	if cfg.Intervals == nil {
+		cfg.Intervals = apm.ZerologIntervals(cfg.Logger)
+	}
+
+	if cfg.Tracer == nil {
+		cfg.Tracer = apm.NopTracer()
+	}
+
 	res := &Server{
 		config:     cfg,
-		interval:   apm.NewZerologInterval(cfg.Logger, "session.Server."),
+		interval:   cfg.Intervals("session.Server."),
 		masterConn: &connWrapper{},
+		rooms:      map[uint64]*roomWrapper{},
+		closed:     make(chan struct{}),
 		condRooms:  sync.NewCond(&sync.Mutex{}),
 	}
 
+	res.initMetrics()
+
+	if cfg.MetricsAddress != "" {
+		res.metricsServer = &http.Server{
+			Addr:              cfg.MetricsAddress,
+			Handler:           cfg.Metrics.Handler(),
+			ReadHeaderTimeout: constants.DefaultTimeout,
+		}
+	}
+
 	res.masterConn.parent = res
 	res.masterConn.init()
 
@@ -82,6 +166,9 @@ func New(cfg Config) (*Server, error) {
 	return res, nil
 }
 
+// Serve processes commands of master until ctx is done.
+// Returns nil after Close or ctx is done.
+// Authorization error is returned with sentinel from constants, e.g. constants.ErrBadToken.
 func (s *Server) Serve(ctx context.Context) error {
 	defer s.interval.Start("Serve").End()
 
@@ -92,12 +179,47 @@ func (s *Server) Serve(ctx context.Context) error {
 			s.config.Logger.Err(err).Stack().Send()
 		})
 
-	return s.masterConn.Serve()
+	if s.metricsServer != nil {
+		go s.serveMetrics()
+	}
+
+	err := s.masterConn.Serve(ctx)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.authErr != nil {
+		return s.authErr
+	}
+
+	if errors.Is(err, transport.ErrClosed) {
+		return nil
+	}
+
+	return err
+}
+
+func (s *Server) serveMetrics() {
+	defer s.interval.Start("serveMetrics").End()
+
+	err := s.metricsServer.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		s.config.Logger.Err(errors.WithStack(err)).Stack().Send()
+	}
 }
 
 func (s *Server) Close() error {
 	defer s.interval.Start("Close").End()
 
+	s.closeOnce.Do(func() { close(s.closed) })
+
+	if s.metricsServer != nil {
+		err := s.metricsServer.Close()
+		if err != nil {
+			s.config.Logger.Err(errors.WithStack(err)).Stack().Send()
+		}
+	}
+
 	return s.masterConn.Close()
 }
 
@@ -114,15 +236,34 @@ func (s *Server) AuthClient(token []byte, client net.Conn) error {
 func (s *Server) getCapacity() uint64 {
 	defer s.interval.Start("getCapacity").End()
 
-	// TODO: add normal calculations
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if uint64(len(s.rooms)) >= s.config.Capacity {
+		return 0
+	}
+
+	return s.config.Capacity - uint64(len(s.rooms))
+}
+
+// reportStats sends actual stats to master.
+func (s *Server) reportStats() {
+	defer s.interval.Start("reportStats").End()
 
-	return 1
+	s.masterConn.Stats(&proto.Stats{
+		Capacity: s.getCapacity(),
+	})
 }
 
-func (s *Server) onAuthError(errText string) {
+// onAuthError stops server, err is returned from Serve.
+func (s *Server) onAuthError(authErr error) {
 	defer s.interval.Start("onAuthError").End()
 
-	s.config.Logger.Err(errors.New(errText)).Send()
+	s.config.Logger.Err(authErr).Send()
+
+	s.mu.Lock()
+	s.authErr = authErr
+	s.mu.Unlock()
 
 	err := s.Close()
 	if err != nil {
@@ -133,40 +274,225 @@ func (s *Server) onAuthError(errText string) {
 func (s *Server) onRoomCreate(room *proto.Room) {
 	defer s.interval.Start("onRoomCreate").End()
 
+	s.createRoom(room)
+	s.reportStats()
+}
+
+func (s *Server) onRoomCreateBatch(rooms []*proto.Room) {
+	defer s.interval.Start("onRoomCreateBatch").End()
+
+	for _, room := range rooms {
+		s.createRoom(room)
+	}
+
+	s.reportStats()
+}
+
+// createRoom starts room. Span of creation is child of master span from room.Trace and replaces it.
+func (s *Server) createRoom(room *proto.Room) {
+	ctx := apm.ContextWithTraceParent(apm.ContextWithRoomID(context.Background(), room.ID), room.Trace)
+
+	ctx, interval := apm.StartContext(ctx, s.interval, "createRoom")
+	defer interval.End()
+
+	ctx, span := s.config.Tracer.Start(ctx, "session.createRoom")
+	defer span.End()
+
+	span.SetAttribute("room.id", room.ID)
+	room.Trace = span.Context().TraceParent()
+
+	s.mu.Lock()
+
+	if existing, ok := s.rooms[room.ID]; ok {
+		s.mu.Unlock()
+		s.masterConn.RoomCreated(existing.roomData)
+
+		return
+	}
+
+	full := uint64(len(s.rooms)) >= s.config.Capacity
+
+	s.mu.Unlock()
+
+	if full {
+		err := errors.WithStack(constants.ErrCapacity)
+		span.SetError(err)
+
+		s.roomError(room, err, map[string]string{
+			"capacity": strconv.FormatUint(s.config.Capacity, 10),
+		})
+
+		return
+	}
+
+	engine := s.config.EngineFactory.New()
+	s.instrument(engine)
+
+	err := s.initEngine(ctx, engine, room)
+	if err != nil {
+		err = errors.WithMessage(constants.ErrEngineInit, err.Error())
+		span.SetError(err)
+
+		s.config.Logger.Err(err).Stack().Send()
+
+		s.roomError(room, err, nil)
+
+		return
+	}
+
 	roomInstance := roomWrapper{
 		roomData: room,
 		parent:   s,
 	}
 	roomInstance.init()
 
-	s.rooms = append(s.rooms, &roomInstance)
+	s.mu.Lock()
+	s.rooms[room.ID] = &roomInstance
+	s.mu.Unlock()
 
-	go roomInstance.Serve(s.config.EngineFactory.New())
+	go roomInstance.Serve(engine)
+
+	s.metrics.roomsCreated.Inc()
 
 	// TODO: add client sockets.
 
 	s.masterConn.RoomCreated(room)
 }
 
+// initEngine initializes engine for room within span if engine implements engine.Initializer.
+func (s *Server) initEngine(ctx context.Context, instance engine.Engine, room *proto.Room) error {
+	initializer, ok := instance.(engine.Initializer)
+	if !ok {
+		return nil
+	}
+
+	ctx, interval := apm.StartContext(ctx, s.interval, "initEngine")
+	defer interval.End()
+
+	_, span := s.config.Tracer.Start(ctx, "session.engineInit")
+	defer span.End()
+
+	err := initializer.Init(room)
+	span.SetError(err)
+
+	return err
+}
+
+// roomError reports failed room creation.
+func (s *Server) roomError(room *proto.Room, err error, details map[string]string) {
+	defer s.interval.Start("roomError").End()
+
+	room.Error = proto.NewError(err)
+	room.Error.Details = details
+
+	s.metrics.roomsFailed.Inc()
+
+	err = s.masterConn.RoomError(room)
+	if err != nil {
+		s.config.Logger.Err(err).Stack().Send()
+	}
+}
+
 func (s *Server) onRoomCancel(roomID uint64) {
 	defer s.interval.Start("onRoomCancel").End()
 
-	s.removeRoom(roomID)
+	if s.removeRoom(roomID) != nil {
+		s.reportStats()
+	}
 }
 
 func (s *Server) onSessionEnd(roomID uint64) {
 	defer s.interval.Start("onSessionEnd").End()
 
-	roomResult := s.removeRoom(roomID)
-	s.masterConn.RoomFinished(roomResult)
+	room := s.runningRoom(roomID)
+	if room == nil {
+		// cancelled.
+		return
+	}
+
+	// room keeps its slot until result is stored, so master doesn't send new rooms while queue is full.
+	if !s.queueResult(room) {
+		return
+	}
+
+	if s.removeRoom(roomID) == nil {
+		// cancelled while result was stored.
+		return
+	}
+
+	s.metrics.roomsFinished.Inc()
+	s.reportStats()
+}
+
+// queueResult sends result of room, stores it in queue until there is space.
+// Returns false if room is cancelled or server is closed before result is stored.
+func (s *Server) queueResult(room *proto.Room) bool {
+	defer s.interval.Start("queueResult").End()
+
+	for {
+		err := s.masterConn.RoomFinished(room)
+		if err == nil {
+			return true
+		}
+
+		s.config.Logger.Err(err).Stack().Send()
+
+		select {
+		case <-s.closed:
+			return false
+		case <-time.After(resultRetryInterval):
+		}
+
+		if s.runningRoom(room.ID) == nil {
+			return false
+		}
+	}
 }
 
+// runningRoom returns nil if room isn't running.
+func (s *Server) runningRoom(roomID uint64) *proto.Room {
+	defer s.interval.Start("runningRoom").End()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	room, ok := s.rooms[roomID]
+	if !ok {
+		return nil
+	}
+
+	return room.roomData
+}
+
+// removeRoom returns nil if room isn't running.
 func (s *Server) removeRoom(roomID uint64) *proto.Room {
-	res := proto.Room{
-		ID: roomID,
+	defer s.interval.Start("removeRoom").End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, ok := s.rooms[roomID]
+	if !ok {
+		return nil
 	}
 
-	// TODO: implement.
+	delete(s.rooms, roomID)
+
+	return room.roomData
+}
+
+// inventory returns all running rooms.
+func (s *Server) inventory() []*proto.Room {
+	defer s.interval.Start("inventory").End()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := make([]*proto.Room, 0, len(s.rooms))
+
+	for _, room := range s.rooms {
+		res = append(res, room.roomData)
+	}
 
-	return &res
+	return res
 }
@@ -0,0 +1,114 @@
+package session
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/opoccomaxao-go/ipc/event"
+	"github.com/opoccomaxao-go/ipc/transport"
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/engine"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type plainFactory struct{}
+
+func (plainFactory) New() engine.Engine {
+	return struct{}{}
+}
+
+// TestServer_PlainEngine checks that engine without Init is started.
+func TestServer_PlainEngine(t *testing.T) {
+	t.Parallel()
+
+	local, remote := net.Pipe()
+	defer remote.Close()
+
+	created := make(chan uint16, 1)
+
+	go func() {
+		peer := transport.NewSocket(remote)
+
+		var buffer event.Common
+
+		for peer.Read(&buffer) == nil {
+			if buffer.Type == proto.CommandSessionRoomCreated {
+				created <- buffer.Type
+			}
+		}
+	}()
+
+	server := newFuzzServer(local)
+	server.config.EngineFactory = plainFactory{}
+	server.masterConn.setCodec(proto.JSON, true)
+
+	server.createRoom(fuzzRoom(1))
+
+	<-created
+	assert.NotNil(t, server.runningRoom(1))
+}
+
+// TestServer_QueueOverflow checks that finished room keeps its slot until result is stored in queue.
+func TestServer_QueueOverflow(t *testing.T) {
+	t.Parallel()
+
+	local, remote := net.Pipe()
+	defer remote.Close()
+
+	go func() {
+		peer := transport.NewSocket(remote)
+
+		var buffer event.Common
+
+		for peer.Read(&buffer) == nil {
+		}
+	}()
+
+	queue := NewMemoryQueue(1)
+	require.NoError(t, queue.Push(&QueueItem{
+		Command: proto.CommandSessionRoomFinished,
+		Room:    &proto.Room{ID: 100},
+	}))
+
+	server := newFuzzServer(local)
+	server.config.Queue = queue
+
+	room := &roomWrapper{
+		roomData: fuzzRoom(1),
+		parent:   server,
+	}
+	room.init()
+
+	server.rooms[1] = room
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		server.onSessionEnd(1)
+	}()
+
+	select {
+	case <-done:
+		require.Fail(t, "result is dropped")
+	case <-time.After(resultRetryInterval * 2):
+	}
+
+	assert.NotNil(t, server.runningRoom(1), "room keeps slot")
+
+	require.NoError(t, queue.Ack(100))
+	<-done
+
+	assert.Nil(t, server.runningRoom(1))
+
+	items, err := queue.Items()
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, uint64(1), items[0].Room.ID)
+
+	require.ErrorIs(t, server.masterConn.RoomFinished(fuzzRoom(2)), constants.ErrOverflow)
+}
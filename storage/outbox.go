@@ -0,0 +1,23 @@
+package storage
+
+import "github.com/opoccomaxao-go/rooms/proto"
+
+// OutboxEntry is a single finished room with its position in Outbox.
+type OutboxEntry struct {
+	Offset   uint64      `json:"offset"`
+	ServerID uint64      `json:"server_id"` // ServerID duplicates Room.ServerID which isn't serialized.
+	Room     *proto.Room `json:"room"`
+}
+
+// Outbox is ordered log of finished rooms with consumer offsets.
+type Outbox interface {
+	// Append stores room and returns its offset. Offsets start from 1.
+	// Returns constants.ErrOverflow if room can't be stored without loss of unacknowledged rooms.
+	Append(room *proto.Room) (uint64, error)
+	// Read returns up to limit entries with offset >= from.
+	Read(from uint64, limit int) ([]*OutboxEntry, error)
+	// Ack stores last processed offset of consumer. Offset never moves backward.
+	Ack(consumer string, offset uint64) error
+	// Offset returns last acknowledged offset of consumer, 0 if none.
+	Offset(consumer string) (uint64, error)
+}
@@ -0,0 +1,254 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/opoccomaxao-go/rooms/utils"
+	"github.com/pkg/errors"
+)
+
+// FileOutbox is RAMOutbox backed by append-only JSON lines file.
+// File is compacted when most of its records are stale.
+type FileOutbox struct {
+	*RAMOutbox
+
+	path    string
+	file    *os.File
+	encoder *json.Encoder
+	records int
+	mu      sync.Mutex
+}
+
+// outboxCompactMin is minimal count of records in file before compaction.
+const outboxCompactMin = 1000
+
+type outboxRecord struct {
+	Entry    *OutboxEntry `json:"entry,omitempty"`
+	Consumer string       `json:"consumer,omitempty"`
+	Offset   uint64       `json:"offset,omitempty"`
+	Last     uint64       `json:"last,omitempty"`
+}
+
+// implements interface.
+var _ Outbox = (*FileOutbox)(nil)
+
+// NewFileOutbox opens or creates file and restores entries and offsets from it.
+// Zero capacity means unlimited entries in memory.
+func NewFileOutbox(path string, capacity int) (*FileOutbox, error) {
+	//nolint:gomnd // file permissions.
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := &FileOutbox{
+		RAMOutbox: NewRAMOutbox(capacity),
+		path:      path,
+		file:      file,
+		encoder:   json.NewEncoder(file),
+	}
+
+	err = res.restore()
+	if err != nil {
+		_ = file.Close()
+
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// restore applies all records of file, partial last record left by crash is dropped.
+func (o *FileOutbox) restore() error {
+	return utils.ReadLines(o.file, func(line []byte) error {
+		var record outboxRecord
+
+		o.records++
+
+		err := json.Unmarshal(line, &record)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if record.Last > o.last {
+			o.last = record.Last
+		}
+
+		if record.Consumer != "" {
+			o.RAMOutbox.ack(record.Consumer, record.Offset)
+
+			return nil
+		}
+
+		if record.Entry == nil {
+			return nil
+		}
+
+		if record.Entry.Room == nil {
+			return errors.Wrapf(constants.ErrInvalid, "outbox entry %d", record.Entry.Offset)
+		}
+
+		record.Entry.Room.ServerID = record.Entry.ServerID
+		o.RAMOutbox.evict()
+		o.RAMOutbox.push(record.Entry)
+
+		return nil
+	})
+}
+
+func (o *FileOutbox) Append(room *proto.Room) (uint64, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.RAMOutbox.mu.Lock()
+	defer o.RAMOutbox.mu.Unlock()
+
+	err := o.reserve()
+	if err != nil {
+		return 0, err
+	}
+
+	entry := &OutboxEntry{
+		Offset:   o.last + 1,
+		ServerID: room.ServerID,
+		Room:     room,
+	}
+
+	err = o.write(&outboxRecord{Entry: entry})
+	if err != nil {
+		return 0, err
+	}
+
+	o.RAMOutbox.push(entry)
+
+	return entry.Offset, nil
+}
+
+func (o *FileOutbox) Ack(consumer string, offset uint64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.RAMOutbox.mu.Lock()
+	defer o.RAMOutbox.mu.Unlock()
+
+	err := o.write(&outboxRecord{
+		Consumer: consumer,
+		Offset:   offset,
+	})
+	if err != nil {
+		return err
+	}
+
+	o.RAMOutbox.ack(consumer, offset)
+
+	return nil
+}
+
+// write appends record to file and compacts file if needed. Both mutexes must be held.
+func (o *FileOutbox) write(record *outboxRecord) error {
+	if o.records >= outboxCompactMin && o.records >= 2*(len(o.entries)+len(o.offsets)) {
+		err := o.compact()
+		if err != nil {
+			return err
+		}
+	}
+
+	err := o.encoder.Encode(record)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	o.records++
+
+	return nil
+}
+
+// compact rewrites file with current offsets and entries only. Both mutexes must be held.
+func (o *FileOutbox) compact() error {
+	tmpPath := o.path + ".tmp"
+
+	//nolint:gomnd // file permissions.
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	records, err := o.dump(json.NewEncoder(tmp))
+	if err == nil {
+		err = tmp.Sync()
+	}
+
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(tmpPath, o.path)
+	}
+
+	if err != nil {
+		_ = os.Remove(tmpPath)
+
+		return errors.WithStack(err)
+	}
+
+	//nolint:gomnd // file permissions.
+	file, err := os.OpenFile(o.path, os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	_ = o.file.Close()
+
+	o.file = file
+	o.encoder = json.NewEncoder(file)
+	o.records = records
+
+	return nil
+}
+
+// dump writes last offset, consumer offsets and entries to encoder, returns count of written records.
+func (o *FileOutbox) dump(encoder *json.Encoder) (int, error) {
+	err := encoder.Encode(&outboxRecord{Last: o.last})
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	consumers := make([]string, 0, len(o.offsets))
+	for consumer := range o.offsets {
+		consumers = append(consumers, consumer)
+	}
+
+	sort.Strings(consumers)
+
+	for _, consumer := range consumers {
+		err := encoder.Encode(&outboxRecord{
+			Consumer: consumer,
+			Offset:   o.offsets[consumer],
+		})
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+	}
+
+	for _, entry := range o.entries {
+		err := encoder.Encode(&outboxRecord{Entry: entry})
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+	}
+
+	return 1 + len(consumers) + len(o.entries), nil
+}
+
+func (o *FileOutbox) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return errors.WithStack(o.file.Close())
+}
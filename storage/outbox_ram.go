@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"sync"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/pkg/errors"
+)
+
+type RAMOutbox struct {
+	capacity int
+	entries  []*OutboxEntry
+	last     uint64
+	offsets  map[string]uint64
+	mu       sync.RWMutex
+}
+
+// implements interface.
+var _ Outbox = (*RAMOutbox)(nil)
+
+// NewRAMOutbox creates Outbox which keeps up to capacity entries. Zero capacity means unlimited.
+// Only entries acknowledged by all known consumers are evicted, Append returns constants.ErrOverflow
+// if outbox is full of unacknowledged entries. Consumer is known after Offset or Ack.
+func NewRAMOutbox(capacity int) *RAMOutbox {
+	return &RAMOutbox{
+		capacity: capacity,
+		offsets:  map[string]uint64{},
+	}
+}
+
+func (o *RAMOutbox) Append(room *proto.Room) (uint64, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	err := o.reserve()
+	if err != nil {
+		return 0, err
+	}
+
+	entry := &OutboxEntry{
+		Offset:   o.last + 1,
+		ServerID: room.ServerID,
+		Room:     room,
+	}
+
+	o.push(entry)
+
+	return entry.Offset, nil
+}
+
+// reserve evicts acknowledged entries and checks free space for new entry.
+func (o *RAMOutbox) reserve() error {
+	o.evict()
+
+	if o.capacity > 0 && len(o.entries) >= o.capacity {
+		return errors.Wrapf(constants.ErrOverflow, "outbox capacity %d", o.capacity)
+	}
+
+	return nil
+}
+
+func (o *RAMOutbox) push(entry *OutboxEntry) {
+	o.last = entry.Offset
+	o.entries = append(o.entries, entry)
+}
+
+// evict frees place for one entry by removing oldest entries acknowledged by all consumers.
+func (o *RAMOutbox) evict() {
+	if o.capacity <= 0 || len(o.entries) < o.capacity {
+		return
+	}
+
+	committed := o.committed()
+
+	count := 0
+	for len(o.entries)-count >= o.capacity && o.entries[count].Offset <= committed {
+		count++
+	}
+
+	if count > 0 {
+		o.entries = append(o.entries[:0:0], o.entries[count:]...)
+	}
+}
+
+// committed returns minimal offset acknowledged by all consumers.
+// Without consumers all entries are committed, latest ones are kept for new consumer.
+func (o *RAMOutbox) committed() uint64 {
+	res := o.last
+
+	for _, offset := range o.offsets {
+		if offset < res {
+			res = offset
+		}
+	}
+
+	return res
+}
+
+func (o *RAMOutbox) Read(from uint64, limit int) ([]*OutboxEntry, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	if len(o.entries) == 0 || from > o.last {
+		return nil, nil
+	}
+
+	start := 0
+	if first := o.entries[0].Offset; from > first {
+		start = int(from - first)
+	}
+
+	end := len(o.entries)
+	if limit > 0 && start+limit < end {
+		end = start + limit
+	}
+
+	return append([]*OutboxEntry(nil), o.entries[start:end]...), nil
+}
+
+func (o *RAMOutbox) Ack(consumer string, offset uint64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.ack(consumer, offset)
+
+	return nil
+}
+
+func (o *RAMOutbox) ack(consumer string, offset uint64) {
+	if current, ok := o.offsets[consumer]; !ok || offset > current {
+		o.offsets[consumer] = offset
+	}
+}
+
+func (o *RAMOutbox) Offset(consumer string) (uint64, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	// consumer keeps entries from eviction since first read of offset.
+	if _, ok := o.offsets[consumer]; !ok {
+		o.offsets[consumer] = 0
+	}
+
+	return o.offsets[consumer], nil
+}
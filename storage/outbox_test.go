@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testOutbox(t *testing.T, outbox Outbox) {
+	t.Helper()
+
+	for i := uint64(1); i <= 5; i++ {
+		offset, err := outbox.Append(&proto.Room{ID: i * 10, ServerID: i})
+		require.NoError(t, err)
+		require.Equal(t, i, offset)
+	}
+
+	entries, err := outbox.Read(2, 2)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, uint64(2), entries[0].Offset)
+	assert.Equal(t, uint64(20), entries[0].Room.ID)
+	assert.Equal(t, uint64(3), entries[1].Offset)
+
+	entries, err = outbox.Read(6, 0)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	require.NoError(t, outbox.Ack("consumer", 3))
+	require.NoError(t, outbox.Ack("consumer", 2))
+
+	offset, err := outbox.Offset("consumer")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), offset)
+
+	offset, err = outbox.Offset("other")
+	require.NoError(t, err)
+	assert.Zero(t, offset)
+}
+
+func TestRAMOutbox(t *testing.T) {
+	t.Parallel()
+
+	testOutbox(t, NewRAMOutbox(0))
+}
+
+func TestRAMOutbox_Capacity(t *testing.T) {
+	t.Parallel()
+
+	outbox := NewRAMOutbox(2)
+
+	for i := uint64(1); i <= 5; i++ {
+		_, err := outbox.Append(&proto.Room{ID: i})
+		require.NoError(t, err)
+	}
+
+	entries, err := outbox.Read(1, 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, uint64(4), entries[0].Offset)
+	assert.Equal(t, uint64(5), entries[1].Offset)
+}
+
+func TestRAMOutbox_Unacknowledged(t *testing.T) {
+	t.Parallel()
+
+	outbox := NewRAMOutbox(2)
+
+	_, err := outbox.Offset("consumer")
+	require.NoError(t, err)
+
+	for i := uint64(1); i <= 2; i++ {
+		_, err = outbox.Append(&proto.Room{ID: i})
+		require.NoError(t, err)
+	}
+
+	_, err = outbox.Append(&proto.Room{ID: 3})
+	require.ErrorIs(t, err, constants.ErrOverflow)
+
+	require.NoError(t, outbox.Ack("consumer", 1))
+
+	offset, err := outbox.Append(&proto.Room{ID: 3})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), offset)
+
+	entries, err := outbox.Read(1, 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, uint64(2), entries[0].Offset)
+}
+
+func TestFileOutbox(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "outbox.jsonl")
+
+	outbox, err := NewFileOutbox(path, 0)
+	require.NoError(t, err)
+
+	testOutbox(t, outbox)
+	require.NoError(t, outbox.Close())
+
+	restored, err := NewFileOutbox(path, 0)
+	require.NoError(t, err)
+
+	defer restored.Close()
+
+	offset, err := restored.Offset("consumer")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), offset)
+
+	entries, err := restored.Read(offset+1, 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, uint64(40), entries[0].Room.ID)
+	assert.Equal(t, uint64(4), entries[0].Room.ServerID)
+
+	offset, err = restored.Append(&proto.Room{ID: 60})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(6), offset)
+}
+
+func TestFileOutbox_PartialRecord(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "outbox.jsonl")
+
+	outbox, err := NewFileOutbox(path, 0)
+	require.NoError(t, err)
+
+	_, err = outbox.Append(&proto.Room{ID: 10})
+	require.NoError(t, err)
+	require.NoError(t, outbox.Close())
+
+	appendPartial(t, path)
+
+	outbox, err = NewFileOutbox(path, 0)
+	require.NoError(t, err)
+
+	offset, err := outbox.Append(&proto.Room{ID: 20})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), offset)
+	require.NoError(t, outbox.Close())
+
+	restored, err := NewFileOutbox(path, 0)
+	require.NoError(t, err)
+
+	defer restored.Close()
+
+	entries, err := restored.Read(1, 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, uint64(20), entries[1].Room.ID)
+}
+
+func TestFileOutbox_Compact(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "outbox.jsonl")
+
+	outbox, err := NewFileOutbox(path, 2)
+	require.NoError(t, err)
+
+	const total = 3 * outboxCompactMin
+
+	for i := uint64(1); i <= total; i++ {
+		offset, err := outbox.Append(&proto.Room{ID: i})
+		require.NoError(t, err)
+		require.NoError(t, outbox.Ack("consumer", offset))
+	}
+
+	require.NoError(t, outbox.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Less(t, bytes.Count(data, []byte("\n")), 2*outboxCompactMin)
+
+	restored, err := NewFileOutbox(path, 2)
+	require.NoError(t, err)
+
+	defer restored.Close()
+
+	offset, err := restored.Offset("consumer")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(total), offset)
+
+	entries, err := restored.Read(total, 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, uint64(total), entries[0].Room.ID)
+
+	offset, err = restored.Append(&proto.Room{ID: total + 1})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(total+1), offset)
+}
@@ -38,7 +38,7 @@ func (s *RAM) Add(token string) {
 
 func (s *RAM) Validate(version string, token string) (uint64, error) {
 	if s.version != version {
-		return 0, errors.Wrap(constants.ErrInvalid, "version")
+		return 0, errors.WithStack(constants.ErrVersionMismatch)
 	}
 
 	for i, t := range s.tokens {
@@ -47,7 +47,7 @@ func (s *RAM) Validate(version string, token string) (uint64, error) {
 		}
 	}
 
-	return 0, errors.Wrap(constants.ErrInvalid, "token")
+	return 0, errors.WithStack(constants.ErrBadToken)
 }
 
 func (s *RAM) NewRoom() uint64 {
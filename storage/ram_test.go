@@ -46,12 +46,14 @@ func TestRAM_Validate(t *testing.T) {
 	for _, token := range checkInvalidTokens {
 		id, err := storage.Validate(Version, token)
 		require.ErrorIs(t, err, constants.ErrInvalid)
+		require.ErrorIs(t, err, constants.ErrBadToken)
 		require.Zero(t, id)
 	}
 
 	for _, version := range checkInvalidVersions {
 		id, err := storage.Validate(version, tokens[0])
 		require.ErrorIs(t, err, constants.ErrInvalid)
+		require.ErrorIs(t, err, constants.ErrVersionMismatch)
 		require.Zero(t, id)
 	}
 }
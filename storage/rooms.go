@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type RoomStatus string
+
+const (
+	RoomStatusRequested RoomStatus = "requested"
+	RoomStatusCreated   RoomStatus = "created"
+	RoomStatusError     RoomStatus = "error"
+	RoomStatusFinished  RoomStatus = "finished"
+	RoomStatusLost      RoomStatus = "lost"
+	RoomStatusCancelled RoomStatus = "cancelled"
+)
+
+// RoomEvent is a single step of room lifecycle.
+type RoomEvent struct {
+	Status   RoomStatus      `json:"status"`
+	At       time.Time       `json:"at"`
+	RoomID   uint64          `json:"room_id"`
+	ServerID uint64          `json:"server_id,omitempty"`
+	Clients  []uint64        `json:"clients,omitempty"`
+	Error    string          `json:"error,omitempty"`
+	Result   json.RawMessage `json:"result,omitempty"`
+}
+
+// RoomRecord is accumulated room history.
+type RoomRecord struct {
+	ID          uint64          `json:"id"`
+	ServerID    uint64          `json:"server_id,omitempty"`
+	Clients     []uint64        `json:"clients"`
+	Status      RoomStatus      `json:"status"`
+	Error       string          `json:"error,omitempty"`
+	Result      json.RawMessage `json:"result,omitempty"`
+	RequestedAt time.Time       `json:"requested_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	FinishedAt  time.Time       `json:"finished_at"`
+}
+
+// RoomStore persists rooms history.
+type RoomStore interface {
+	// Append applies event to the room record.
+	Append(event *RoomEvent) error
+	// Room returns record by room id or constants.ErrNotFound.
+	Room(roomID uint64) (*RoomRecord, error)
+	// RoomsByClient returns all rooms with client.
+	RoomsByClient(clientID uint64) ([]*RoomRecord, error)
+	// RoomsByTime returns all rooms requested in range [from, to).
+	RoomsByTime(from, to time.Time) ([]*RoomRecord, error)
+}
+
+// Apply merges event into record.
+func (r *RoomRecord) Apply(event *RoomEvent) {
+	r.ID = event.RoomID
+	r.Status = event.Status
+
+	if event.ServerID != 0 {
+		r.ServerID = event.ServerID
+	}
+
+	if len(event.Clients) > 0 {
+		r.Clients = append([]uint64(nil), event.Clients...)
+	}
+
+	if event.Error != "" {
+		r.Error = event.Error
+	}
+
+	if len(event.Result) > 0 {
+		r.Result = append(json.RawMessage(nil), event.Result...)
+	}
+
+	switch event.Status {
+	case RoomStatusRequested:
+		r.RequestedAt = event.At
+	case RoomStatusCreated:
+		r.CreatedAt = event.At
+	case RoomStatusError, RoomStatusFinished, RoomStatusLost, RoomStatusCancelled:
+		r.FinishedAt = event.At
+	}
+}
+
+// Copy returns deep copy of record.
+func (r *RoomRecord) Copy() *RoomRecord {
+	res := *r
+	res.Clients = append([]uint64(nil), r.Clients...)
+
+	if r.Result != nil {
+		res.Result = append(json.RawMessage(nil), r.Result...)
+	}
+
+	return &res
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/opoccomaxao-go/rooms/utils"
+	"github.com/pkg/errors"
+)
+
+// FileRoomStore is RAMRoomStore backed by append-only JSON lines file.
+type FileRoomStore struct {
+	*RAMRoomStore
+
+	file    *os.File
+	encoder *json.Encoder
+	mu      sync.Mutex
+}
+
+// implements interface.
+var _ RoomStore = (*FileRoomStore)(nil)
+
+// NewFileRoomStore opens or creates file and restores history from it.
+func NewFileRoomStore(path string) (*FileRoomStore, error) {
+	//nolint:gomnd // file permissions.
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := &FileRoomStore{
+		RAMRoomStore: NewRAMRoomStore(),
+		file:         file,
+		encoder:      json.NewEncoder(file),
+	}
+
+	err = res.restore()
+	if err != nil {
+		_ = file.Close()
+
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// restore applies all events of file, partial last event left by crash is dropped.
+func (s *FileRoomStore) restore() error {
+	return utils.ReadLines(s.file, func(line []byte) error {
+		var event RoomEvent
+
+		err := json.Unmarshal(line, &event)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		s.RAMRoomStore.apply(&event)
+
+		return nil
+	})
+}
+
+func (s *FileRoomStore) Append(event *RoomEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.encoder.Encode(event)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return s.RAMRoomStore.Append(event)
+}
+
+func (s *FileRoomStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return errors.WithStack(s.file.Close())
+}
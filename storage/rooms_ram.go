@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"sync"
+	"time"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/pkg/errors"
+	"golang.org/x/exp/slices"
+)
+
+type RAMRoomStore struct {
+	rooms    map[uint64]*RoomRecord
+	byClient map[uint64][]uint64
+	mu       sync.RWMutex
+}
+
+// implements interface.
+var _ RoomStore = (*RAMRoomStore)(nil)
+
+func NewRAMRoomStore() *RAMRoomStore {
+	return &RAMRoomStore{
+		rooms:    map[uint64]*RoomRecord{},
+		byClient: map[uint64][]uint64{},
+	}
+}
+
+func (s *RAMRoomStore) Append(event *RoomEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.apply(event)
+
+	return nil
+}
+
+func (s *RAMRoomStore) apply(event *RoomEvent) {
+	record, ok := s.rooms[event.RoomID]
+	if !ok {
+		record = &RoomRecord{}
+		s.rooms[event.RoomID] = record
+	}
+
+	record.Apply(event)
+
+	for _, clientID := range event.Clients {
+		if !slices.Contains(s.byClient[clientID], event.RoomID) {
+			s.byClient[clientID] = append(s.byClient[clientID], event.RoomID)
+		}
+	}
+}
+
+func (s *RAMRoomStore) Room(roomID uint64) (*RoomRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	record, ok := s.rooms[roomID]
+	if !ok {
+		return nil, errors.Wrapf(constants.ErrNotFound, "room %d", roomID)
+	}
+
+	return record.Copy(), nil
+}
+
+func (s *RAMRoomStore) RoomsByClient(clientID uint64) ([]*RoomRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := s.byClient[clientID]
+	res := make([]*RoomRecord, 0, len(ids))
+
+	for _, id := range ids {
+		res = append(res, s.rooms[id].Copy())
+	}
+
+	sortRecords(res)
+
+	return res, nil
+}
+
+func (s *RAMRoomStore) RoomsByTime(from, to time.Time) ([]*RoomRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := []*RoomRecord{}
+
+	for _, record := range s.rooms {
+		if !record.RequestedAt.Before(from) && record.RequestedAt.Before(to) {
+			res = append(res, record.Copy())
+		}
+	}
+
+	sortRecords(res)
+
+	return res, nil
+}
+
+func sortRecords(records []*RoomRecord) {
+	slices.SortFunc(records, func(a, b *RoomRecord) bool {
+		return a.ID < b.ID
+	})
+}
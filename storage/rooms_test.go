@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRoomStore(t *testing.T, store RoomStore) {
+	t.Helper()
+
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	events := []*RoomEvent{
+		{Status: RoomStatusRequested, At: start, RoomID: 1, Clients: []uint64{10, 11}},
+		{Status: RoomStatusRequested, At: start.Add(time.Minute), RoomID: 2, Clients: []uint64{11}},
+		{Status: RoomStatusCreated, At: start.Add(time.Second), RoomID: 1, ServerID: 5},
+		{Status: RoomStatusError, At: start.Add(time.Minute), RoomID: 2, Error: "failed"},
+		{Status: RoomStatusFinished, At: start.Add(time.Hour), RoomID: 1, Result: json.RawMessage(`{"win":10}`)},
+	}
+
+	for _, event := range events {
+		require.NoError(t, store.Append(event))
+	}
+
+	room, err := store.Room(1)
+	require.NoError(t, err)
+	assert.Equal(t, &RoomRecord{
+		ID:          1,
+		ServerID:    5,
+		Clients:     []uint64{10, 11},
+		Status:      RoomStatusFinished,
+		Result:      json.RawMessage(`{"win":10}`),
+		RequestedAt: start,
+		CreatedAt:   start.Add(time.Second),
+		FinishedAt:  start.Add(time.Hour),
+	}, room)
+
+	_, err = store.Room(3)
+	require.ErrorIs(t, err, constants.ErrNotFound)
+
+	rooms, err := store.RoomsByClient(11)
+	require.NoError(t, err)
+	require.Len(t, rooms, 2)
+	assert.Equal(t, uint64(1), rooms[0].ID)
+	assert.Equal(t, uint64(2), rooms[1].ID)
+	assert.Equal(t, "failed", rooms[1].Error)
+
+	rooms, err = store.RoomsByTime(start.Add(time.Second), start.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, rooms, 1)
+	assert.Equal(t, uint64(2), rooms[0].ID)
+}
+
+func TestRAMRoomStore(t *testing.T) {
+	t.Parallel()
+
+	testRoomStore(t, NewRAMRoomStore())
+}
+
+func TestFileRoomStore(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "rooms.jsonl")
+
+	store, err := NewFileRoomStore(path)
+	require.NoError(t, err)
+
+	testRoomStore(t, store)
+	require.NoError(t, store.Close())
+
+	restored, err := NewFileRoomStore(path)
+	require.NoError(t, err)
+
+	defer restored.Close()
+
+	room, err := restored.Room(1)
+	require.NoError(t, err)
+	assert.Equal(t, RoomStatusFinished, room.Status)
+	assert.Equal(t, []uint64{10, 11}, room.Clients)
+
+	rooms, err := restored.RoomsByClient(11)
+	require.NoError(t, err)
+	require.Len(t, rooms, 2)
+}
+
+// appendPartial appends half of record as crash during write does.
+func appendPartial(t *testing.T, path string) {
+	t.Helper()
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+
+	_, err = file.WriteString(`{"status":"fin`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+}
+
+func TestFileRoomStore_PartialRecord(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "rooms.jsonl")
+
+	store, err := NewFileRoomStore(path)
+	require.NoError(t, err)
+	require.NoError(t, store.Append(&RoomEvent{Status: RoomStatusRequested, RoomID: 1}))
+	require.NoError(t, store.Close())
+
+	appendPartial(t, path)
+
+	store, err = NewFileRoomStore(path)
+	require.NoError(t, err)
+	require.NoError(t, store.Append(&RoomEvent{Status: RoomStatusRequested, RoomID: 2}))
+	require.NoError(t, store.Close())
+
+	restored, err := NewFileRoomStore(path)
+	require.NoError(t, err)
+
+	defer restored.Close()
+
+	for _, id := range []uint64{1, 2} {
+		_, err = restored.Room(id)
+		require.NoError(t, err, "room %d", id)
+	}
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/engine/engtest"
+	"github.com/opoccomaxao-go/rooms/master"
+	"github.com/opoccomaxao-go/rooms/session"
+	"github.com/opoccomaxao-go/rooms/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminCreateRoom(t *testing.T) {
+	t.Parallel()
+
+	const (
+		Address    = ":22190"
+		AuthToken  = "token"
+		AdminToken = "admin"
+	)
+
+	ctx := TestContext(t)
+
+	storage := storage.NewRAM()
+	storage.Add(AuthToken)
+	storage.SetVersion(constants.Version)
+
+	mainServer, err := master.New(master.Config{
+		Storage:        storage,
+		SessionAddress: Address,
+		AdminToken:     AdminToken,
+	})
+	require.NoError(t, err)
+
+	go func() {
+		_ = mainServer.Serve(ctx)
+	}()
+
+	time.Sleep(time.Second) // wait for main
+
+	sessionServer, err := session.New(session.Config{
+		MasterAddress: Address,
+		Token:         []byte(AuthToken),
+		EngineFactory: engtest.New(),
+	})
+	require.NoError(t, err)
+
+	go func() {
+		_ = sessionServer.Serve(ctx)
+	}()
+
+	time.Sleep(time.Second) // wait for session
+
+	handler := mainServer.AdminHandler()
+
+	createRoom := func(authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/rooms", strings.NewReader(`{"clients":[1,2]}`))
+		req.Header.Set("Authorization", authorization)
+
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+
+		return res
+	}
+
+	res := createRoom(AdminToken)
+	assert.Equal(t, http.StatusUnauthorized, res.Code, "no scheme")
+	assert.Empty(t, mainServer.Rooms())
+
+	res = createRoom("Bearer " + AdminToken)
+	require.Equal(t, http.StatusCreated, res.Code, res.Body.String())
+
+	var room master.AdminRoom
+
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&room))
+	assert.NotZero(t, room.ID)
+	assert.Equal(t, uint64(1), room.ServerID)
+	assert.True(t, room.Active)
+	assert.Len(t, room.Clients, 2)
+
+	active, err := mainServer.Room(room.ID)
+	require.NoError(t, err)
+	assert.Equal(t, room.ServerID, active.ServerID)
+}
@@ -0,0 +1,42 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/engine/engtest"
+	"github.com/opoccomaxao-go/rooms/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthError(t *testing.T) {
+	t.Parallel()
+
+	const Address = ":22120"
+
+	ctx := TestContext(t)
+
+	NewCluster(t, ctx, Address, 0, 0)
+
+	sessionServer, err := session.New(session.Config{
+		MasterAddress: Address,
+		Token:         []byte("unknown"),
+		EngineFactory: engtest.New(),
+	})
+	require.NoError(t, err)
+
+	res := make(chan error, 1)
+
+	go func() {
+		res <- sessionServer.Serve(ctx)
+	}()
+
+	select {
+	case err := <-res:
+		require.ErrorIs(t, err, constants.ErrBadToken)
+		require.ErrorIs(t, err, constants.ErrInvalid)
+	case <-time.After(time.Second * 5):
+		require.Fail(t, "timeout")
+	}
+}
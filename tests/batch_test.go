@@ -0,0 +1,44 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/opoccomaxao-go/rooms/master"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateRooms(t *testing.T) {
+	t.Parallel()
+
+	const (
+		Sessions = 2
+		Capacity = 10
+		Rooms    = 15
+	)
+
+	ctx := TestContext(t)
+
+	mainServer := NewCluster(t, ctx, ":22110", Sessions, Capacity)
+
+	specs := make([]master.RoomSpec, Rooms)
+	for i := range specs {
+		specs[i].Clients = []uint64{uint64(i + 1)}
+	}
+
+	results := mainServer.CreateRooms(ctx, specs)
+	require.Len(t, results, Rooms)
+
+	perServer := map[uint64]int{}
+
+	for i, res := range results {
+		require.NoError(t, res.Error)
+		require.NotNil(t, res.Room)
+		require.Len(t, res.Room.Clients, 1)
+		assert.Equal(t, uint64(i+1), res.Room.Clients[0].ID)
+
+		perServer[res.Room.ServerID]++
+	}
+
+	assert.Len(t, perServer, Sessions)
+}
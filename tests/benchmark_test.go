@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/opoccomaxao-go/rooms/engine/engtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// BenchmarkCreateRoom shows CreateRoom throughput with different count of session servers.
+// Room start takes InitDelay, so throughput is limited by session servers and grows with their count.
+// Speedup is throughput relative to one server.
+func BenchmarkCreateRoom(b *testing.B) {
+	const (
+		BasePort        = 22200
+		SessionCapacity = 1 << 20
+		Parallelism     = 16
+		InitDelay       = time.Millisecond * 2
+	)
+
+	var base float64
+
+	for _, servers := range []int{1, 2, 4, 8} {
+		servers := servers
+
+		b.Run(fmt.Sprintf("servers=%d", servers), func(b *testing.B) {
+			ctx := TestContext(b)
+
+			mainServer := NewClusterWithEngine(b, ctx, fmt.Sprintf(":%d", BasePort+servers), servers, SessionCapacity,
+				engtest.NewDelayed(InitDelay))
+
+			b.SetParallelism(servers * Parallelism)
+			b.ResetTimer()
+
+			start := time.Now()
+
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					room, err := mainServer.CreateRoom(ctx, []uint64{1})
+					require.NoError(b, err)
+					require.NotNil(b, room)
+				}
+			})
+
+			throughput := float64(b.N) / time.Since(start).Seconds()
+
+			b.ReportMetric(throughput, "rooms/s")
+
+			if servers == 1 {
+				base = throughput
+			}
+
+			// base is unknown if servers=1 is filtered out.
+			if base == 0 || servers == 1 {
+				return
+			}
+
+			speedup := throughput / base
+
+			b.ReportMetric(speedup, "speedup")
+
+			// short runs are dominated by warm up.
+			if b.N >= 100*servers {
+				assert.Greater(b, speedup, float64(servers)/2, "throughput doesn't scale")
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/engine/engtest"
+	"github.com/opoccomaxao-go/rooms/master"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/opoccomaxao-go/rooms/session"
+	"github.com/opoccomaxao-go/rooms/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBinaryCodec(t *testing.T) {
+	t.Parallel()
+
+	const (
+		Address   = ":22140"
+		AuthToken = "token"
+	)
+
+	ctx := TestContext(t)
+
+	storage := storage.NewRAM()
+	storage.Add(AuthToken)
+	storage.SetVersion(constants.Version)
+
+	mainServer, err := master.New(master.Config{
+		Storage:        storage,
+		SessionAddress: Address,
+	})
+	require.NoError(t, err)
+
+	go func() {
+		_ = mainServer.Serve(ctx)
+	}()
+
+	time.Sleep(time.Second) // wait for main
+
+	sessionServer, err := session.New(session.Config{
+		MasterAddress: Address,
+		Token:         []byte(AuthToken),
+		EngineFactory: engtest.New(),
+		Codec:         proto.Binary,
+	})
+	require.NoError(t, err)
+
+	go func() {
+		_ = sessionServer.Serve(ctx)
+	}()
+
+	time.Sleep(time.Second) // wait for session
+
+	finishedRooms := mainServer.FinishedRooms(ctx)
+
+	room, err := mainServer.CreateRoomFromSpec(ctx, master.RoomSpec{
+		Clients: []uint64{1},
+		Parties: []master.PartySpec{
+			{Team: 1, Clients: []uint64{2, 3}},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, room.Clients, 3)
+
+	finished := <-finishedRooms
+	require.NotNil(t, finished)
+	assert.Equal(t, room.ID, finished.ID)
+	assert.Equal(t, room.Clients, finished.Clients)
+}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/engine/engtest"
+	"github.com/opoccomaxao-go/rooms/master"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/opoccomaxao-go/rooms/session"
+	"github.com/opoccomaxao-go/rooms/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomCommands(t *testing.T) {
+	t.Parallel()
+
+	const (
+		Address   = ":22170"
+		AuthToken = "token"
+
+		CommandConfig = proto.CommandCustomMin + 1
+		CommandState  = proto.CommandCustomMin + 2
+	)
+
+	type received struct {
+		serverID uint64
+		payload  []byte
+	}
+
+	ctx := TestContext(t)
+
+	storage := storage.NewRAM()
+	storage.Add(AuthToken)
+	storage.SetVersion(constants.Version)
+
+	_, err := master.New(master.Config{
+		Storage:  storage,
+		Commands: map[uint16]master.CommandHandler{proto.CommandSessionMalformed: func(uint64, []byte) {}},
+	})
+	require.ErrorIs(t, err, constants.ErrInvalid)
+
+	masterReceived := make(chan received, 1)
+
+	mainServer, err := master.New(master.Config{
+		Storage:        storage,
+		SessionAddress: Address,
+		Commands: map[uint16]master.CommandHandler{
+			CommandState: func(serverID uint64, payload []byte) {
+				masterReceived <- received{serverID: serverID, payload: payload}
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	go func() {
+		_ = mainServer.Serve(ctx)
+	}()
+
+	time.Sleep(time.Second) // wait for main
+
+	sessionReceived := make(chan []byte, 1)
+
+	sessionServer, err := session.New(session.Config{
+		MasterAddress: Address,
+		Token:         []byte(AuthToken),
+		EngineFactory: engtest.New(),
+		Codec:         proto.Binary,
+		Commands: map[uint16]session.CommandHandler{
+			CommandConfig: func(payload []byte) {
+				sessionReceived <- payload
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	go func() {
+		_ = sessionServer.Serve(ctx)
+	}()
+
+	time.Sleep(time.Second) // wait for session
+
+	require.NoError(t, sessionServer.SendCommand(CommandState, []byte("state")))
+
+	var state received
+
+	select {
+	case state = <-masterReceived:
+		assert.NotZero(t, state.serverID)
+		assert.Equal(t, []byte("state"), state.payload)
+	case <-time.After(time.Second):
+		require.FailNow(t, "state isn't received")
+	}
+
+	require.NoError(t, mainServer.SendCommand(state.serverID, CommandConfig, []byte("config")))
+
+	select {
+	case payload := <-sessionReceived:
+		assert.Equal(t, []byte("config"), payload)
+	case <-time.After(time.Second):
+		require.FailNow(t, "config isn't received")
+	}
+
+	assert.ErrorIs(t, mainServer.SendCommand(state.serverID+1, CommandConfig, nil), constants.ErrNotFound)
+	assert.ErrorIs(t, mainServer.SendCommand(state.serverID, proto.CommandMasterRoomAck, nil), constants.ErrInvalid)
+	assert.ErrorIs(t, sessionServer.SendCommand(CommandState, make([]byte, proto.MaxPayloadSize+1)), constants.ErrOverflow)
+}
@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"bytes"
 	"context"
 	"testing"
 	"time"
@@ -56,10 +57,13 @@ func TestFlow(t *testing.T) {
 
 	time.Sleep(time.Second) // wait for main
 
+	queue := session.NewMemoryQueue(0)
+
 	sessionServer, err := session.New(session.Config{
 		MasterAddress: constants.DefaultAddress,
 		Token:         []byte(AuthToken),
 		EngineFactory: engtest.New(),
+		Queue:         queue,
 		Logger:        &logger,
 	})
 	require.NoError(t, err)
@@ -70,6 +74,11 @@ func TestFlow(t *testing.T) {
 
 	time.Sleep(time.Second) // wait for session
 
+	consumer, err := mainServer.ConsumeFinishedRooms(ctx, master.ConsumerConfig{
+		Name: "test",
+	})
+	require.NoError(t, err)
+
 	room, err := mainServer.CreateRoom(ctx, []uint64{UserID})
 	require.NoError(t, err)
 	require.NotNil(t, room)
@@ -83,5 +92,27 @@ func TestFlow(t *testing.T) {
 		ServerID: 1,
 	}, room)
 
+	finished := <-consumer.C
+	require.NotNil(t, finished.Room)
+	assert.Equal(t, room.ID, finished.Room.ID)
+	assert.Equal(t, room.ServerID, finished.Room.ServerID)
+	require.NoError(t, consumer.Ack(finished.Offset))
+
+	require.Eventually(t, func() bool {
+		return queue.Len() == 0
+	}, time.Second, 10*time.Millisecond)
+
+	var metrics bytes.Buffer
+
+	require.NoError(t, mainServer.Metrics().WriteText(&metrics))
+	assert.Contains(t, metrics.String(), "rooms_master_rooms_created_total 1\n")
+	assert.Contains(t, metrics.String(), "rooms_master_rooms_finished_total 1\n")
+	assert.Contains(t, metrics.String(), "rooms_master_room_create_duration_seconds_count 1\n")
+
+	metrics.Reset()
+
+	require.NoError(t, sessionServer.Metrics().WriteText(&metrics))
+	assert.Contains(t, metrics.String(), "rooms_session_rooms_finished_total 1\n")
+
 	// TODO: implement.
 }
@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/engine/engtest"
+	"github.com/opoccomaxao-go/rooms/master"
+	"github.com/opoccomaxao-go/rooms/proto"
+	"github.com/opoccomaxao-go/rooms/session"
+	"github.com/opoccomaxao-go/rooms/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRoomSpec checks that engine receives teams and parties of room spec with every codec.
+func TestRoomSpec(t *testing.T) {
+	t.Parallel()
+
+	const AuthToken = "token"
+
+	for address, codec := range map[string]proto.Codec{
+		":22180": proto.JSON,
+		":22181": proto.Binary,
+	} {
+		address := address
+		codec := codec
+
+		t.Run(codec.Name(), func(t *testing.T) {
+			t.Parallel()
+
+			ctx := TestContext(t)
+
+			storage := storage.NewRAM()
+			storage.Add(AuthToken)
+			storage.SetVersion(constants.Version)
+
+			mainServer, err := master.New(master.Config{
+				Storage:        storage,
+				SessionAddress: address,
+			})
+			require.NoError(t, err)
+
+			go func() {
+				_ = mainServer.Serve(ctx)
+			}()
+
+			time.Sleep(time.Second) // wait for main
+
+			factory := &engtest.Factory{}
+
+			sessionServer, err := session.New(session.Config{
+				MasterAddress: address,
+				Token:         []byte(AuthToken),
+				EngineFactory: factory,
+				Codec:         codec,
+			})
+			require.NoError(t, err)
+
+			go func() {
+				_ = sessionServer.Serve(ctx)
+			}()
+
+			time.Sleep(time.Second) // wait for session
+
+			room, err := mainServer.CreateRoomFromSpec(ctx, master.RoomSpec{
+				Clients: []uint64{1},
+				Parties: []master.PartySpec{
+					{Team: 1, Clients: []uint64{2, 3}},
+					{Team: 2, Clients: []uint64{4}},
+				},
+			})
+			require.NoError(t, err)
+
+			rooms := factory.Rooms()
+			require.Len(t, rooms, 1)
+			assert.Equal(t, room.ID, rooms[0].ID)
+
+			clients := map[uint64][2]uint64{}
+			for _, client := range rooms[0].Clients {
+				clients[client.ID] = [2]uint64{uint64(client.Team), client.Party}
+			}
+
+			assert.Equal(t, map[uint64][2]uint64{
+				1: {0, 0},
+				2: {1, 1},
+				3: {1, 1},
+				4: {2, 2},
+			}, clients, "team and party of clients")
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opoccomaxao-go/rooms/apm"
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/engine/engtest"
+	"github.com/opoccomaxao-go/rooms/master"
+	"github.com/opoccomaxao-go/rooms/session"
+	"github.com/opoccomaxao-go/rooms/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrace(t *testing.T) {
+	t.Parallel()
+
+	const (
+		Address   = ":22130"
+		AuthToken = "token"
+	)
+
+	ctx := TestContext(t)
+	exporter := apm.NewMemoryExporter()
+
+	storage := storage.NewRAM()
+	storage.Add(AuthToken)
+	storage.SetVersion(constants.Version)
+
+	mainServer, err := master.New(master.Config{
+		Storage:        storage,
+		SessionAddress: Address,
+		Tracer:         apm.NewTracer("master", exporter),
+	})
+	require.NoError(t, err)
+
+	go func() {
+		_ = mainServer.Serve(ctx)
+	}()
+
+	time.Sleep(time.Second) // wait for main
+
+	sessionServer, err := session.New(session.Config{
+		MasterAddress: Address,
+		Token:         []byte(AuthToken),
+		EngineFactory: engtest.New(),
+		Tracer:        apm.NewTracer("session", exporter),
+	})
+	require.NoError(t, err)
+
+	go func() {
+		_ = sessionServer.Serve(ctx)
+	}()
+
+	time.Sleep(time.Second) // wait for session
+
+	room, err := mainServer.CreateRoom(ctx, []uint64{1})
+	require.NoError(t, err)
+
+	parent, err := apm.ParseTraceParent(room.Trace)
+	require.NoError(t, err)
+
+	spans := map[string]*apm.SpanData{}
+
+	for _, span := range exporter.Trace(parent.TraceID) {
+		spans[span.Name] = span
+	}
+
+	require.Contains(t, spans, "master.CreateRoom")
+	require.Contains(t, spans, "master.RoomCreate")
+	require.Contains(t, spans, "session.createRoom")
+	require.Contains(t, spans, "session.engineInit")
+	require.Contains(t, spans, "master.onRoomCreated")
+
+	assert.Equal(t, "session", spans["session.createRoom"].Service)
+	assert.Equal(t, spans["master.CreateRoom"].SpanID, spans["master.RoomCreate"].ParentID)
+	assert.Equal(t, spans["master.RoomCreate"].SpanID, spans["session.createRoom"].ParentID)
+	assert.Equal(t, spans["session.createRoom"].SpanID, spans["session.engineInit"].ParentID)
+	assert.Equal(t, spans["session.createRoom"].SpanID, spans["master.onRoomCreated"].ParentID)
+}
@@ -2,13 +2,79 @@ package tests
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
+
+	"github.com/opoccomaxao-go/rooms/constants"
+	"github.com/opoccomaxao-go/rooms/engine"
+	"github.com/opoccomaxao-go/rooms/engine/engtest"
+	"github.com/opoccomaxao-go/rooms/master"
+	"github.com/opoccomaxao-go/rooms/session"
+	"github.com/opoccomaxao-go/rooms/storage"
+	"github.com/stretchr/testify/require"
 )
 
-func TestContext(t *testing.T) context.Context {
+func TestContext(t testing.TB) context.Context {
 	ctx, cancelFn := context.WithCancel(context.Background())
 
 	t.Cleanup(cancelFn)
 
 	return ctx
 }
+
+// NewCluster starts master with sessions session servers, each with specified capacity.
+func NewCluster(t testing.TB, ctx context.Context, address string, sessions int, capacity uint64) *master.Server {
+	t.Helper()
+
+	return NewClusterWithEngine(t, ctx, address, sessions, capacity, engtest.New())
+}
+
+// NewClusterWithEngine starts cluster as NewCluster, session servers use factory.
+func NewClusterWithEngine(
+	t testing.TB,
+	ctx context.Context,
+	address string,
+	sessions int,
+	capacity uint64,
+	factory engine.Factory,
+) *master.Server {
+	t.Helper()
+
+	storage := storage.NewRAM()
+	storage.SetVersion(constants.Version)
+
+	for i := 0; i < sessions; i++ {
+		storage.Add(fmt.Sprintf("token-%d", i))
+	}
+
+	mainServer, err := master.New(master.Config{
+		Storage:        storage,
+		SessionAddress: address,
+	})
+	require.NoError(t, err)
+
+	go func() {
+		_ = mainServer.Serve(ctx)
+	}()
+
+	time.Sleep(time.Second) // wait for main
+
+	for i := 0; i < sessions; i++ {
+		sessionServer, err := session.New(session.Config{
+			MasterAddress: address,
+			Token:         []byte(fmt.Sprintf("token-%d", i)),
+			EngineFactory: factory,
+			Capacity:      capacity,
+		})
+		require.NoError(t, err)
+
+		go func() {
+			_ = sessionServer.Serve(ctx)
+		}()
+	}
+
+	time.Sleep(time.Second) // wait for session
+
+	return mainServer
+}
@@ -16,6 +16,16 @@ func (c *Channels[T]) Notify(value T) {
 	}
 }
 
+// TryNotify sends value only to channels ready to receive without blocking.
+func (c *Channels[T]) TryNotify(value T) {
+	for _, v := range c.internal {
+		select {
+		case v <- value:
+		default:
+		}
+	}
+}
+
 func (c *Channels[T]) Close() {
 	for _, v := range c.internal {
 		close(v)
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// ReadLines calls fn for every complete non-empty line of file from start.
+// Partial last line, e.g. left by crash during append, is truncated, so next append starts from new line.
+func ReadLines(file *os.File, fn func(line []byte) error) error {
+	_, err := file.Seek(0, io.SeekStart)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	reader := bufio.NewReader(file)
+
+	var offset int64
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) == 0 {
+				return nil
+			}
+
+			return errors.WithStack(file.Truncate(offset))
+		}
+
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		offset += int64(len(line))
+
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		err = fn(line)
+		if err != nil {
+			return err
+		}
+	}
+}
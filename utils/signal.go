@@ -0,0 +1,37 @@
+package utils
+
+import "sync"
+
+// Signal is broadcast notification usable in select.
+//
+//	select {
+//	case <-signal.Wait():
+//	case <-ctx.Done():
+//	}
+type Signal struct {
+	internal chan struct{}
+	mu       sync.Mutex
+}
+
+func NewSignal() *Signal {
+	return &Signal{
+		internal: make(chan struct{}),
+	}
+}
+
+// Wait returns channel which is closed on next Broadcast.
+func (s *Signal) Wait() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.internal
+}
+
+// Broadcast wakes up all waiters.
+func (s *Signal) Broadcast() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	close(s.internal)
+	s.internal = make(chan struct{})
+}